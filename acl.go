@@ -0,0 +1,129 @@
+package mindmeld
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strings"
+
+	"google.golang.org/grpc/peer"
+
+	"github.com/dhowden/mindmeld/pb"
+)
+
+// accessList is the parsed form of a pb.AccessList.
+type accessList struct {
+	x *pb.AccessList
+
+	identities map[string]bool
+	groups     map[string]bool
+	nets       []*net.IPNet
+}
+
+func newAccessList(x *pb.AccessList) (*accessList, error) {
+	a := &accessList{
+		x:          x,
+		identities: make(map[string]bool),
+		groups:     make(map[string]bool),
+	}
+	for _, id := range x.GetIdentities() {
+		a.identities[id] = true
+	}
+	for _, g := range x.GetGroups() {
+		a.groups[g] = true
+	}
+	for _, cidr := range x.GetCidrs() {
+		_, n, err := net.ParseCIDR(cidr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid CIDR %q: %w", cidr, err)
+		}
+		a.nets = append(a.nets, n)
+	}
+	return a, nil
+}
+
+func (a *accessList) empty() bool {
+	return len(a.identities) == 0 && len(a.groups) == 0 && len(a.nets) == 0
+}
+
+// allows returns true if the caller identified by id and connecting from addr
+// (which can be nil) matches the access list.
+func (a *accessList) allows(id *Identity, addr net.Addr) bool {
+	if a.empty() {
+		return true
+	}
+
+	if id.Name != "" && a.identities[id.Name] {
+		return true
+	}
+	for _, g := range id.Groups {
+		if a.groups[g] {
+			return true
+		}
+	}
+
+	return a.allowsAddr(addr)
+}
+
+// allowsAddr returns true if addr is in one of the networks of the access
+// list.
+func (a *accessList) allowsAddr(addr net.Addr) bool {
+	if ip := addrIP(addr); ip != nil {
+		for _, n := range a.nets {
+			if n.Contains(ip) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// addrIP returns the IP from addr, or nil if there isn't one.
+func addrIP(addr net.Addr) net.IP {
+	switch a := addr.(type) {
+	case *net.TCPAddr:
+		return a.IP
+	case *net.UDPAddr:
+		return a.IP
+	case nil:
+		return nil
+	}
+
+	host, _, err := net.SplitHostPort(addr.String())
+	if err != nil {
+		return nil
+	}
+	return net.ParseIP(host)
+}
+
+// peerAddr returns the address of the gRPC peer for the request with ctx.
+func peerAddr(ctx context.Context) net.Addr {
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return nil
+	}
+	return p.Addr
+}
+
+// ParseAccessList creates an access list from entries.  Entries prefixed
+// with "group:" are groups, entries in CIDR notation are networks, and all
+// others are identities.
+func ParseAccessList(entries []string) (*pb.AccessList, error) {
+	x := &pb.AccessList{}
+	for _, e := range entries {
+		switch {
+		case strings.HasPrefix(e, "group:"):
+			x.Groups = append(x.Groups, strings.TrimPrefix(e, "group:"))
+
+		case strings.Contains(e, "/"):
+			if _, _, err := net.ParseCIDR(e); err != nil {
+				return nil, fmt.Errorf("invalid CIDR %q: %w", e, err)
+			}
+			x.Cidrs = append(x.Cidrs, e)
+
+		default:
+			x.Identities = append(x.Identities, e)
+		}
+	}
+	return x, nil
+}
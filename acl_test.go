@@ -0,0 +1,102 @@
+package mindmeld_test
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/dhowden/mindmeld"
+	"github.com/dhowden/mindmeld/pb"
+)
+
+func TestForwardAccessList(t *testing.T) {
+	r := newAuthRouter(t, map[string]*mindmeld.Identity{
+		"alice": {Name: "alice"},
+		"bob":   {Name: "bob"},
+		"carol": {Name: "carol", Groups: []string{"dev"}},
+		"dave":  {Name: "dave", Groups: []string{"ops"}},
+	})
+
+	allow, err := mindmeld.ParseAccessList([]string{"bob", "group:dev"})
+	if err != nil {
+		t.Fatalf("ParseAccessList() = %v", err)
+	}
+
+	cc := tokenConn(t, r, "alice")
+	l, err := mindmeld.Listen(context.Background(), cc, "db", mindmeld.WithAccessList(allow))
+	if err != nil {
+		t.Fatalf("Listen() = %v", err)
+	}
+	defer l.Close()
+	waitForService(t, cc, "db")
+
+	tests := []struct {
+		token string
+		code  codes.Code
+	}{
+		{"alice", codes.OK},
+		{"bob", codes.OK},
+		{"carol", codes.OK},
+		{"dave", codes.NotFound}, // services are hidden from callers who aren't allowed
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.token, func(t *testing.T) {
+			_, err := pb.NewControlServiceClient(tokenConn(t, r, tt.token)).ForwardToService(context.Background(), &pb.ForwardToServiceRequest{
+				Name: "db",
+			})
+			if status.Code(err) != tt.code {
+				t.Errorf("ForwardToService() = %v, want code %v", err, tt.code)
+			}
+		})
+	}
+
+	// Services are only listed for callers allowed to forward to them, and
+	// access lists only for the owner.
+	listed := func(token string) *pb.Service {
+		resp, err := pb.NewControlServiceClient(tokenConn(t, r, token)).ListServices(context.Background(), &pb.ListServicesRequest{})
+		if err != nil {
+			t.Fatalf("ListServices() = %v", err)
+		}
+		for _, svc := range resp.GetServices() {
+			if svc.GetName() == "db" {
+				return svc
+			}
+		}
+		return nil
+	}
+	if svc := listed("alice"); len(svc.GetAllow().GetIdentities()) != 1 {
+		t.Errorf("ListServices() for owner = %v, expected access list", svc)
+	}
+	if svc := listed("bob"); svc == nil || svc.GetAllow() != nil {
+		t.Errorf("ListServices() for allowed caller = %v, expected service without access list", svc)
+	}
+	if svc := listed("dave"); svc != nil {
+		t.Errorf("ListServices() for caller not allowed = %v, expected service to be hidden", svc)
+	}
+}
+
+func TestParseAccessList(t *testing.T) {
+	got, err := mindmeld.ParseAccessList([]string{"alice", "group:dev", "10.0.0.0/8", "bob"})
+	if err != nil {
+		t.Fatalf("ParseAccessList() = %v", err)
+	}
+
+	want := &pb.AccessList{
+		Identities: []string{"alice", "bob"},
+		Groups:     []string{"dev"},
+		Cidrs:      []string{"10.0.0.0/8"},
+	}
+	if !reflect.DeepEqual(got.GetIdentities(), want.GetIdentities()) ||
+		!reflect.DeepEqual(got.GetGroups(), want.GetGroups()) ||
+		!reflect.DeepEqual(got.GetCidrs(), want.GetCidrs()) {
+		t.Errorf("ParseAccessList() = %v, want %v", got, want)
+	}
+
+	if _, err := mindmeld.ParseAccessList([]string{"10.0.0.0/33"}); err == nil {
+		t.Errorf("ParseAccessList() = nil error for invalid CIDR")
+	}
+}
@@ -0,0 +1,64 @@
+package mindmeld
+
+import (
+	"net"
+	"strings"
+
+	"github.com/dhowden/mindmeld/pb"
+)
+
+var _ net.Addr = (*Addr)(nil)
+
+// Addr is the address of one end of a connection made through the router.
+type Addr struct {
+	// Service the connection is for.
+	Service string
+
+	// Identity of the forwarder (empty if anonymous or unknown).
+	Identity string
+
+	// Addr is the network address of the forwarder, as seen by the router
+	// (empty if unknown).
+	Addr string
+}
+
+func newAddr(service string, p *pb.Peer) *Addr {
+	return &Addr{
+		Service:  service,
+		Identity: p.GetIdentity(),
+		Addr:     p.GetAddr(),
+	}
+}
+
+// Network returns "mindmeld".
+func (a *Addr) Network() string { return "mindmeld" }
+
+// String returns the network address of the forwarder if known (so that it
+// can be parsed as "host:port", i.e. by net/http), otherwise the service name.
+func (a *Addr) String() string {
+	if a.Addr != "" {
+		return a.Addr
+	}
+	return a.Service
+}
+
+// splitAddr splits an address of the form "udp:host:port" or "unix:path"
+// into its network and address.  Addresses without a network prefix are
+// "tcp".
+func splitAddr(addr string) (network, address string) {
+	for _, n := range []string{"tcp", "udp", "unix"} {
+		if strings.HasPrefix(addr, n+":") {
+			return n, strings.TrimPrefix(addr, n+":")
+		}
+	}
+	return "tcp", addr
+}
+
+// protocol returns the protocol used to carry network.  Unix sockets are
+// carried as TCP.
+func protocol(network string) pb.Protocol {
+	if strings.HasPrefix(network, "udp") {
+		return pb.Protocol_UDP
+	}
+	return pb.Protocol_TCP
+}
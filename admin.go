@@ -0,0 +1,147 @@
+package mindmeld
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/dhowden/mindmeld/pb"
+)
+
+var _ pb.AdminServiceServer = (*Server)(nil)
+
+// WithAdmins sets the identities and groups allowed to call the admin
+// service (see ParseAdmins).  Only the identities and groups of x are used:
+// admins must be authenticated, so any networks (Cidrs) in x are ignored and
+// don't make anyone an admin.  By default there are no admins.
+func WithAdmins(x *pb.AccessList) ServerOption {
+	return func(s *Server) {
+		if len(x.GetCidrs()) > 0 {
+			log.Printf("Ignoring networks in admins: %v", x.GetCidrs())
+		}
+		// Without networks the access list can't be invalid.
+		s.admins, _ = newAccessList(&pb.AccessList{
+			Identities: x.GetIdentities(),
+			Groups:     x.GetGroups(),
+		})
+	}
+}
+
+// ParseAdmins parses a list of admins for WithAdmins: identities, and groups
+// prefixed with "group:".  Networks are rejected, as admins must be
+// authenticated.
+func ParseAdmins(entries []string) (*pb.AccessList, error) {
+	x, err := ParseAccessList(entries)
+	if err != nil {
+		return nil, err
+	}
+	if len(x.GetCidrs()) > 0 {
+		return nil, fmt.Errorf("admins must be identities or groups, not networks: %v", x.GetCidrs())
+	}
+	return x, nil
+}
+
+// authorizeAdmin authenticates the caller of the request with ctx, and checks
+// that they are an admin.
+func (s *Server) authorizeAdmin(ctx context.Context) (*Identity, error) {
+	id, err := s.authenticate(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if !s.isAdmin(id) {
+		return nil, status.Errorf(codes.PermissionDenied, "%v is not an admin", id)
+	}
+	return id, nil
+}
+
+// isAdmin returns true if the caller identified by id is an admin.
+func (s *Server) isAdmin(id *Identity) bool {
+	return s.admins != nil && !s.admins.empty() && s.admins.allows(id, nil)
+}
+
+// removeService deletes svc and closes its instances, which ends their
+// CreateService streams.  Must be called with mu held, and followed by
+// forgetService once mu is released.
+func (s *Server) removeService(svc *service) {
+	s.deleteService(svc)
+	svc.closeInstances()
+}
+
+// DeleteService deletes a service hosted by this router, closing its
+// connections and ending its CreateService streams.
+func (s *Server) DeleteService(ctx context.Context, r *pb.DeleteServiceRequest) (*pb.DeleteServiceResponse, error) {
+	id, err := s.authorizeAdmin(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	svc, ok := s.services[r.GetName()]
+	if !ok {
+		s.mu.Unlock()
+		return nil, status.Errorf(codes.NotFound, "service %q does not exist", r.GetName())
+	}
+	s.removeService(svc)
+	conns := s.serviceConns(svc.name)
+	s.mu.Unlock()
+	s.forgetService(svc)
+
+	for _, fwd := range conns {
+		fwd.close()
+	}
+
+	log.Printf("Deleted service %q (by: %v, connections: %d)", svc.name, id, len(conns))
+	return &pb.DeleteServiceResponse{}, nil
+}
+
+// CloseConnection closes a connection to a service hosted by this router.
+func (s *Server) CloseConnection(ctx context.Context, r *pb.CloseConnectionRequest) (*pb.CloseConnectionResponse, error) {
+	id, err := s.authorizeAdmin(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.RLock()
+	fwd, ok := s.conns[r.GetId()]
+	s.mu.RUnlock()
+	if !ok {
+		return nil, status.Errorf(codes.NotFound, "connection %d does not exist", r.GetId())
+	}
+	fwd.close()
+
+	log.Printf("Closed connection %d to service %q (by: %v)", fwd.id, fwd.service, id)
+	return &pb.CloseConnectionResponse{}, nil
+}
+
+// DrainService stops a service hosted by this router from accepting new
+// forwards.  The service is deleted when its existing connections have
+// finished.
+func (s *Server) DrainService(ctx context.Context, r *pb.DrainServiceRequest) (*pb.DrainServiceResponse, error) {
+	id, err := s.authorizeAdmin(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	svc, ok := s.services[r.GetName()]
+	if !ok {
+		s.mu.Unlock()
+		return nil, status.Errorf(codes.NotFound, "service %q does not exist", r.GetName())
+	}
+	svc.drain()
+
+	n := len(s.serviceConns(svc.name))
+	log.Printf("Draining service %q (by: %v, connections: %d)", svc.name, id, n)
+	if n == 0 {
+		s.removeService(svc)
+	}
+	s.mu.Unlock()
+
+	if n == 0 {
+		s.forgetService(svc)
+	}
+	return &pb.DrainServiceResponse{}, nil
+}
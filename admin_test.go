@@ -0,0 +1,145 @@
+package mindmeld_test
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/dhowden/mindmeld"
+	"github.com/dhowden/mindmeld/pb"
+)
+
+// waitForDeleted polls the router until the service name is deleted.
+func waitForDeleted(t *testing.T, cc *grpc.ClientConn, name string) {
+	t.Helper()
+
+	waitForServices(t, cc, fmt.Sprintf("%q to be deleted", name), func(svcs []*pb.Service) bool {
+		for _, svc := range svcs {
+			if svc.GetName() == name {
+				return false
+			}
+		}
+		return true
+	})
+}
+
+func TestAdmin(t *testing.T) {
+	r := newAuthRouter(t, map[string]*mindmeld.Identity{
+		"alice": {Name: "alice"},
+		"bob":   {Name: "bob"},
+		"carol": {Name: "carol", Groups: []string{"ops"}},
+	}, mindmeld.WithAdmins(&pb.AccessList{Groups: []string{"ops"}}))
+	alice, bob, carol := tokenConn(t, r, "alice"), tokenConn(t, r, "bob"), tokenConn(t, r, "carol")
+	admin := pb.NewAdminServiceClient(carol)
+
+	listen := func(name string) {
+		l, err := mindmeld.Listen(context.Background(), alice, name)
+		if err != nil {
+			t.Fatalf("Listen() = %v", err)
+		}
+		t.Cleanup(func() { l.Close() })
+		go serveName(l, name)
+		waitForService(t, alice, name)
+	}
+
+	t.Run("PermissionDenied", func(t *testing.T) {
+		listen("denied")
+		_, err := pb.NewAdminServiceClient(alice).DeleteService(context.Background(), &pb.DeleteServiceRequest{Name: "denied"})
+		if status.Code(err) != codes.PermissionDenied {
+			t.Errorf("DeleteService() by non-admin = %v, expected code %v", err, codes.PermissionDenied)
+		}
+	})
+
+	t.Run("CloseConnection", func(t *testing.T) {
+		listen("close")
+		c, _ := dialName(t, bob, "close")
+		defer c.Close()
+
+		conns := waitForConnections(t, carol, func(conns []*pb.Connection) bool { return len(conns) == 1 })
+		if _, err := admin.CloseConnection(context.Background(), &pb.CloseConnectionRequest{Id: conns[0].GetId()}); err != nil {
+			t.Fatalf("CloseConnection() = %v", err)
+		}
+		ioutil.ReadAll(c) // returns when the connection is closed
+		waitForConnections(t, carol, func(conns []*pb.Connection) bool { return len(conns) == 0 })
+
+		_, err := admin.CloseConnection(context.Background(), &pb.CloseConnectionRequest{Id: conns[0].GetId()})
+		if status.Code(err) != codes.NotFound {
+			t.Errorf("CloseConnection() for closed connection = %v, expected code %v", err, codes.NotFound)
+		}
+
+		// The service is unaffected.
+		dialRead(t, bob, "close")
+	})
+
+	t.Run("DrainService", func(t *testing.T) {
+		listen("drain")
+		c, _ := dialName(t, bob, "drain")
+
+		if _, err := admin.DrainService(context.Background(), &pb.DrainServiceRequest{Name: "drain"}); err != nil {
+			t.Fatalf("DrainService() = %v", err)
+		}
+		_, err := pb.NewControlServiceClient(bob).ForwardToService(context.Background(), &pb.ForwardToServiceRequest{Name: "drain"})
+		if status.Code(err) != codes.Unavailable {
+			t.Errorf("ForwardToService() to draining service = %v, expected code %v", err, codes.Unavailable)
+		}
+
+		// Existing connections continue until they finish.
+		if _, err := c.Write([]byte("hello")); err != nil {
+			t.Errorf("could not write to draining service: %v", err)
+		}
+		waitForConnections(t, carol, func(conns []*pb.Connection) bool {
+			return len(conns) == 1 && conns[0].GetBytesUp() == 5
+		})
+		c.Close()
+		waitForDeleted(t, alice, "drain")
+	})
+
+	t.Run("DeleteService", func(t *testing.T) {
+		listen("delete")
+		c, _ := dialName(t, bob, "delete")
+		defer c.Close()
+
+		// Another instance, to check that its stream ends.
+		csc, err := pb.NewControlServiceClient(alice).CreateService(context.Background(), &pb.CreateServiceRequest{Name: "delete"})
+		if err != nil {
+			t.Fatalf("CreateService() = %v", err)
+		}
+		if _, err := csc.Header(); err != nil {
+			t.Fatalf("Header() = %v", err)
+		}
+
+		if _, err := admin.DeleteService(context.Background(), &pb.DeleteServiceRequest{Name: "delete"}); err != nil {
+			t.Fatalf("DeleteService() = %v", err)
+		}
+		if _, err := csc.Recv(); status.Code(err) != codes.Aborted {
+			t.Errorf("Recv() = %v after service deleted, expected code %v", err, codes.Aborted)
+		}
+		ioutil.ReadAll(c) // returns when the connection is closed
+		waitForConnections(t, carol, func(conns []*pb.Connection) bool { return len(conns) == 0 })
+		waitForDeleted(t, alice, "delete")
+
+		_, err = admin.DeleteService(context.Background(), &pb.DeleteServiceRequest{Name: "delete"})
+		if status.Code(err) != codes.NotFound {
+			t.Errorf("DeleteService() for deleted service = %v, expected code %v", err, codes.NotFound)
+		}
+	})
+}
+
+func TestParseAdmins(t *testing.T) {
+	got, err := mindmeld.ParseAdmins([]string{"alice", "group:ops"})
+	if err != nil {
+		t.Fatalf("ParseAdmins() = %v", err)
+	}
+	if len(got.GetIdentities()) != 1 || len(got.GetGroups()) != 1 {
+		t.Errorf("ParseAdmins() = %v, expected 1 identity and 1 group", got)
+	}
+
+	if _, err := mindmeld.ParseAdmins([]string{"alice", "10.0.0.0/8"}); err == nil {
+		t.Errorf("ParseAdmins() = nil error for network")
+	}
+}
@@ -0,0 +1,172 @@
+package mindmeld
+
+import (
+	"bufio"
+	"context"
+	"crypto/subtle"
+	"fmt"
+	"io"
+	"strings"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// Identity of an authenticated caller.
+type Identity struct {
+	// Name of the caller.  Empty for anonymous callers.
+	Name string
+
+	// Groups the caller belongs to.
+	Groups []string
+}
+
+func (id *Identity) String() string {
+	if id.Name == "" {
+		return "anonymous"
+	}
+	return id.Name
+}
+
+// anonymous is the identity used for all callers when no Authenticator is
+// configured.
+var anonymous = &Identity{}
+
+// Authenticator authenticates control requests.
+type Authenticator interface {
+	// Authenticate returns the identity of the caller.  The context is the
+	// context of the gRPC request, and so carries the incoming metadata and
+	// peer information.  Errors should be gRPC status errors
+	// (typically codes.Unauthenticated).
+	Authenticate(ctx context.Context) (*Identity, error)
+}
+
+// authorizationKey is the metadata key which holds bearer tokens.
+const authorizationKey = "authorization"
+
+// NewTokenAuthenticator creates an Authenticator which authenticates callers
+// using a static set of bearer tokens, passed in the "authorization" metadata
+// as "Bearer <token>".  The tokens map token -> identity.
+func NewTokenAuthenticator(tokens map[string]*Identity) Authenticator {
+	return &tokenAuthenticator{
+		tokens: tokens,
+	}
+}
+
+type tokenAuthenticator struct {
+	tokens map[string]*Identity
+}
+
+func (a *tokenAuthenticator) Authenticate(ctx context.Context) (*Identity, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	for _, v := range md.Get(authorizationKey) {
+		token := strings.TrimPrefix(v, "Bearer ")
+		if token == v {
+			continue
+		}
+
+		for t, id := range a.tokens {
+			if subtle.ConstantTimeCompare([]byte(t), []byte(token)) == 1 {
+				return id, nil
+			}
+		}
+		return nil, status.Errorf(codes.Unauthenticated, "invalid bearer token")
+	}
+	return nil, status.Errorf(codes.Unauthenticated, "missing bearer token")
+}
+
+// ReadTokens reads bearer tokens for NewTokenAuthenticator from r.  Each
+// line is of the form "<token> <identity> [<group>,<group>...]".  Empty lines
+// and lines beginning with # are ignored.
+func ReadTokens(r io.Reader) (map[string]*Identity, error) {
+	tokens := make(map[string]*Identity)
+
+	s := bufio.NewScanner(r)
+	for n := 1; s.Scan(); n++ {
+		line := strings.TrimSpace(s.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		fields := strings.Fields(line)
+		if len(fields) != 2 && len(fields) != 3 {
+			return nil, fmt.Errorf("line %d: expected \"<token> <identity> [<groups>]\", got %d fields", n, len(fields))
+		}
+
+		id := &Identity{Name: fields[1]}
+		if len(fields) == 3 {
+			id.Groups = strings.Split(fields[2], ",")
+		}
+		tokens[fields[0]] = id
+	}
+	if err := s.Err(); err != nil {
+		return nil, fmt.Errorf("could not read tokens: %w", err)
+	}
+	return tokens, nil
+}
+
+// NewCertificateAuthenticator creates an Authenticator which identifies
+// callers by the common name of their (verified) TLS client certificate.
+// The organizational units of the certificate are used as groups.
+// The gRPC server must be configured to require and verify client
+// certificates (tls.RequireAndVerifyClientCert).
+func NewCertificateAuthenticator() Authenticator {
+	return certificateAuthenticator{}
+}
+
+type certificateAuthenticator struct{}
+
+func (certificateAuthenticator) Authenticate(ctx context.Context) (*Identity, error) {
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return nil, status.Errorf(codes.Unauthenticated, "no peer for request")
+	}
+
+	tlsInfo, ok := p.AuthInfo.(credentials.TLSInfo)
+	if !ok {
+		return nil, status.Errorf(codes.Unauthenticated, "connection is not using TLS")
+	}
+
+	chains := tlsInfo.State.VerifiedChains
+	if len(chains) == 0 || len(chains[0]) == 0 {
+		return nil, status.Errorf(codes.Unauthenticated, "no verified client certificate")
+	}
+
+	subject := chains[0][0].Subject
+	if subject.CommonName == "" {
+		return nil, status.Errorf(codes.Unauthenticated, "client certificate has no common name")
+	}
+	return &Identity{
+		Name:   subject.CommonName,
+		Groups: subject.OrganizationalUnit,
+	}, nil
+}
+
+var _ credentials.PerRPCCredentials = BearerToken{}
+
+// BearerToken is a credentials.PerRPCCredentials which passes a token to be
+// checked by the router's token Authenticator.  Use with
+// grpc.WithPerRPCCredentials.
+type BearerToken struct {
+	// Token to send.
+	Token string
+
+	// AllowInsecure allows the token to be sent over connections without
+	// transport security.
+	AllowInsecure bool
+}
+
+// GetRequestMetadata implements credentials.PerRPCCredentials.
+func (t BearerToken) GetRequestMetadata(ctx context.Context, uri ...string) (map[string]string, error) {
+	return map[string]string{
+		authorizationKey: "Bearer " + t.Token,
+	}, nil
+}
+
+// RequireTransportSecurity implements credentials.PerRPCCredentials.
+func (t BearerToken) RequireTransportSecurity() bool {
+	return !t.AllowInsecure
+}
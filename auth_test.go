@@ -0,0 +1,223 @@
+package mindmeld_test
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"net"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	"github.com/dhowden/mindmeld"
+	"github.com/dhowden/mindmeld/pb"
+)
+
+func TestTokenAuthenticator(t *testing.T) {
+	r := NewTestRouter(t, mindmeld.WithAuthenticator(mindmeld.NewTokenAuthenticator(map[string]*mindmeld.Identity{
+		"secret": {Name: "alice"},
+	})))
+
+	tests := []struct {
+		name  string
+		opts  []grpc.DialOption
+		owner string
+		code  codes.Code
+	}{
+		{
+			name:  "valid",
+			opts:  []grpc.DialOption{grpc.WithPerRPCCredentials(mindmeld.BearerToken{Token: "secret", AllowInsecure: true})},
+			owner: "alice",
+		},
+		{
+			name: "invalid",
+			opts: []grpc.DialOption{grpc.WithPerRPCCredentials(mindmeld.BearerToken{Token: "wrong", AllowInsecure: true})},
+			code: codes.Unauthenticated,
+		},
+		{
+			name: "missing",
+			code: codes.Unauthenticated,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cc := r.ClientConn(t, tt.opts...)
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			csc, err := pb.NewControlServiceClient(cc).CreateService(ctx, &pb.CreateServiceRequest{
+				Name: tt.name,
+			})
+			if err != nil {
+				t.Fatalf("CreateService() = %v", err)
+			}
+
+			if tt.code != codes.OK {
+				if _, err := csc.Recv(); status.Code(err) != tt.code {
+					t.Errorf("Recv() = %v, want code %v", err, tt.code)
+				}
+				return
+			}
+
+			waitForService(t, cc, tt.name)
+
+			resp, err := pb.NewControlServiceClient(cc).ListServices(ctx, &pb.ListServicesRequest{})
+			if err != nil {
+				t.Fatalf("ListServices() = %v", err)
+			}
+			for _, svc := range resp.GetServices() {
+				if svc.GetName() == tt.name && svc.GetOwner() != tt.owner {
+					t.Errorf("service owner = %q, want %q", svc.GetOwner(), tt.owner)
+				}
+			}
+		})
+	}
+}
+
+// issueCert creates a certificate from tmpl, signed by parent (or self-signed
+// if parent is nil).
+func issueCert(t *testing.T, tmpl *x509.Certificate, parent *tls.Certificate) tls.Certificate {
+	t.Helper()
+
+	pub, key, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatalf("could not generate key: %v", err)
+	}
+	tmpl.SerialNumber = big.NewInt(time.Now().UnixNano())
+	tmpl.NotBefore = time.Now().Add(-time.Minute)
+	tmpl.NotAfter = time.Now().Add(time.Hour)
+
+	issuer, signer := tmpl, interface{}(key)
+	if parent != nil {
+		issuer, signer = parent.Leaf, parent.PrivateKey
+	}
+	der, err := x509.CreateCertificate(nil, tmpl, issuer, pub, signer)
+	if err != nil {
+		t.Fatalf("could not create certificate: %v", err)
+	}
+	leaf, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatalf("could not parse certificate: %v", err)
+	}
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key, Leaf: leaf}
+}
+
+func TestCertificateAuthenticator(t *testing.T) {
+	ca := issueCert(t, &x509.Certificate{
+		Subject:               pkix.Name{CommonName: "ca"},
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}, nil)
+	pool := x509.NewCertPool()
+	pool.AddCert(ca.Leaf)
+
+	serverCert := issueCert(t, &x509.Certificate{
+		Subject:     pkix.Name{CommonName: "router"},
+		DNSNames:    []string{"bufconn"},
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}, &ca)
+	clientCert := issueCert(t, &x509.Certificate{
+		Subject:     pkix.Name{CommonName: "alice", OrganizationalUnit: []string{"dev"}},
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}, &ca)
+
+	// Client certificates are verified if given, so that callers without one
+	// reach the Authenticator.
+	s := mindmeld.NewServer("", mindmeld.WithAuthenticator(mindmeld.NewCertificateAuthenticator()))
+	gs := grpc.NewServer(grpc.Creds(credentials.NewTLS(&tls.Config{
+		Certificates: []tls.Certificate{serverCert},
+		ClientCAs:    pool,
+		ClientAuth:   tls.VerifyClientCertIfGiven,
+	})))
+	mindmeld.RegisterServer(gs, s)
+
+	l := bufconn.Listen(1024 * 1024)
+	go gs.Serve(l)
+	t.Cleanup(func() {
+		s.Close()
+		gs.Stop()
+	})
+
+	clientConn := func(t *testing.T, certs ...tls.Certificate) *grpc.ClientConn {
+		cc, err := grpc.Dial("bufconn",
+			grpc.WithContextDialer(func(context.Context, string) (net.Conn, error) {
+				return l.Dial()
+			}),
+			grpc.WithTransportCredentials(credentials.NewTLS(&tls.Config{
+				ServerName:   "bufconn",
+				RootCAs:      pool,
+				Certificates: certs,
+			})),
+		)
+		if err != nil {
+			t.Fatalf("unexpected error from grpc.Dial: %v", err)
+		}
+		t.Cleanup(func() { cc.Close() })
+		return cc
+	}
+
+	t.Run("certificate", func(t *testing.T) {
+		cc := clientConn(t, clientCert)
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		if _, err := pb.NewControlServiceClient(cc).CreateService(ctx, &pb.CreateServiceRequest{Name: "svc"}); err != nil {
+			t.Fatalf("CreateService() = %v", err)
+		}
+		waitForService(t, cc, "svc")
+
+		resp, err := pb.NewControlServiceClient(cc).ListServices(ctx, &pb.ListServicesRequest{})
+		if err != nil {
+			t.Fatalf("ListServices() = %v", err)
+		}
+		if got := resp.GetServices()[0].GetOwner(); got != "alice" {
+			t.Errorf("service owner = %q, want %q", got, "alice")
+		}
+	})
+
+	t.Run("no certificate", func(t *testing.T) {
+		cc := clientConn(t)
+		csc, err := pb.NewControlServiceClient(cc).CreateService(context.Background(), &pb.CreateServiceRequest{Name: "anon"})
+		if err != nil {
+			t.Fatalf("CreateService() = %v", err)
+		}
+		if _, err := csc.Recv(); status.Code(err) != codes.Unauthenticated {
+			t.Errorf("Recv() = %v, want code %v", err, codes.Unauthenticated)
+		}
+	})
+}
+
+func TestReadTokens(t *testing.T) {
+	const input = `# comment
+secret1 alice
+
+secret2 bob dev,ops
+`
+	got, err := mindmeld.ReadTokens(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("ReadTokens() = %v", err)
+	}
+	want := map[string]*mindmeld.Identity{
+		"secret1": {Name: "alice"},
+		"secret2": {Name: "bob", Groups: []string{"dev", "ops"}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ReadTokens() = %v, want %v", got, want)
+	}
+
+	if _, err := mindmeld.ReadTokens(strings.NewReader("secret1\n")); err == nil {
+		t.Errorf("ReadTokens() = nil error for invalid line")
+	}
+}
@@ -0,0 +1,34 @@
+package mindmeld
+
+import (
+	"math/rand"
+	"sync/atomic"
+
+	"github.com/dhowden/mindmeld/pb"
+)
+
+// pick an instance from instances (which must be non-empty) using the load
+// balancing policy.  The next index is used (and updated) for round-robin.
+func pick(policy pb.LoadBalancing, instances []*instance, next *int) *instance {
+	switch policy {
+	case pb.LoadBalancing_LEAST_CONNECTIONS:
+		// Start from next so that ties are spread between instances.
+		n := len(instances)
+		best := instances[*next%n]
+		for i := 1; i < n; i++ {
+			x := instances[(*next+i)%n]
+			if atomic.LoadInt64(&x.active) < atomic.LoadInt64(&best.active) {
+				best = x
+			}
+		}
+		*next = (*next + 1) % n
+		return best
+
+	case pb.LoadBalancing_RANDOM:
+		return instances[rand.Intn(len(instances))]
+	}
+
+	inst := instances[*next%len(instances)]
+	*next = (*next + 1) % len(instances)
+	return inst
+}
@@ -0,0 +1,131 @@
+package mindmeld_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"google.golang.org/grpc"
+
+	"github.com/dhowden/mindmeld"
+	"github.com/dhowden/mindmeld/pb"
+)
+
+// waitForInstances polls the router until the service has n instances.
+func waitForInstances(t *testing.T, cc *grpc.ClientConn, name string, n int32) {
+	t.Helper()
+
+	waitForServices(t, cc, fmt.Sprintf("%d instances of %q", n, name), func(svcs []*pb.Service) bool {
+		for _, svc := range svcs {
+			if svc.GetName() == name && svc.GetInstances() == n {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+func TestRoundRobin(t *testing.T) {
+	r := NewTestRouter(t)
+	cc := r.ClientConn(t)
+
+	for _, name := range []string{"a", "b"} {
+		l, err := mindmeld.Listen(context.Background(), cc, "svc")
+		if err != nil {
+			t.Fatalf("Listen() = %v", err)
+		}
+		defer l.Close()
+		go serveName(l, name)
+	}
+	waitForInstances(t, cc, "svc", 2)
+
+	got := make(map[string]int)
+	for i := 0; i < 4; i++ {
+		got[dialRead(t, cc, "svc")]++
+	}
+	if got["a"] != 2 || got["b"] != 2 {
+		t.Errorf("got %v, want 2 connections to each instance", got)
+	}
+}
+
+func TestFailover(t *testing.T) {
+	r := NewTestRouter(t)
+	cc := r.ClientConn(t)
+
+	la, err := mindmeld.Listen(context.Background(), cc, "svc", mindmeld.WithLoadBalancing(pb.LoadBalancing_RANDOM))
+	if err != nil {
+		t.Fatalf("Listen() = %v", err)
+	}
+	go serveName(la, "a")
+
+	lb, err := mindmeld.Listen(context.Background(), cc, "svc")
+	if err != nil {
+		t.Fatalf("Listen() = %v", err)
+	}
+	defer lb.Close()
+	go serveName(lb, "b")
+
+	waitForInstances(t, cc, "svc", 2)
+	la.Close()
+	waitForInstances(t, cc, "svc", 1)
+
+	for i := 0; i < 4; i++ {
+		if got := dialRead(t, cc, "svc"); got != "b" {
+			t.Errorf("got %q, want %q", got, "b")
+		}
+	}
+}
+
+func TestLeastConnections(t *testing.T) {
+	r := newAuthRouter(t, map[string]*mindmeld.Identity{"alice": {Name: "alice"}})
+	cc := tokenConn(t, r, "alice")
+
+	for _, name := range []string{"a", "b"} {
+		l, err := mindmeld.Listen(context.Background(), cc, "svc", mindmeld.WithLoadBalancing(pb.LoadBalancing_LEAST_CONNECTIONS))
+		if err != nil {
+			t.Fatalf("Listen() = %v", err)
+		}
+		defer l.Close()
+		go serveName(l, name)
+	}
+	waitForInstances(t, cc, "svc", 2)
+
+	// Hold a connection open to one instance, so that new connections go to
+	// the other.
+	c, busy := dialName(t, cc, "svc")
+	defer c.Close()
+
+	for i := 0; i < 4; i++ {
+		if got := dialRead(t, cc, "svc"); got == busy {
+			t.Errorf("got %q, want the instance without connections", got)
+		}
+		// Wait for the connection to finish, leaving the held connection.
+		waitForConnections(t, cc, func(conns []*pb.Connection) bool { return len(conns) == 1 })
+	}
+}
+
+func TestRandom(t *testing.T) {
+	r := NewTestRouter(t)
+	cc := r.ClientConn(t)
+
+	for _, name := range []string{"a", "b"} {
+		l, err := mindmeld.Listen(context.Background(), cc, "svc", mindmeld.WithLoadBalancing(pb.LoadBalancing_RANDOM))
+		if err != nil {
+			t.Fatalf("Listen() = %v", err)
+		}
+		defer l.Close()
+		go serveName(l, name)
+	}
+	waitForInstances(t, cc, "svc", 2)
+
+	// Each instance is picked with probability 1/2, so the chance of one
+	// getting no connections is 2^-19.
+	const n = 20
+	got := make(map[string]int)
+	for i := 0; i < n; i++ {
+		got[dialRead(t, cc, "svc")]++
+	}
+	if got["a"] == 0 || got["b"] == 0 || got["a"]+got["b"] != n {
+		t.Errorf("got %v, want connections to each instance", got)
+	}
+}
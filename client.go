@@ -2,11 +2,15 @@ package mindmeld
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"log"
 	"net"
+	"os"
 	"sync"
+	"sync/atomic"
+	"syscall"
 	"time"
 
 	"google.golang.org/grpc"
@@ -23,17 +27,24 @@ type ServiceClient struct {
 	cc *grpc.ClientConn
 
 	name, target string
+	opts         *options
+	pd           *proxyDialer
 
 	doneOnce sync.Once
 	done     chan bool
 }
 
-// NewServiceClient creates a new ServiceClient.
-func NewServiceClient(cc *grpc.ClientConn, name, target string) *ServiceClient {
+// NewServiceClient creates a new ServiceClient, which forwards traffic to
+// target.  Use "udp:host:port" for target to register a UDP service, or
+// "unix:path" to forward to a unix socket.
+func NewServiceClient(cc *grpc.ClientConn, name, target string, opts ...Option) *ServiceClient {
+	o := newOptions(opts)
 	return &ServiceClient{
 		cc:     cc,
 		name:   name,
 		target: target,
+		opts:   o,
+		pd:     newProxyDialer(cc, o.multiplex),
 		done:   make(chan bool),
 	}
 }
@@ -41,53 +52,146 @@ func NewServiceClient(cc *grpc.ClientConn, name, target string) *ServiceClient {
 // Register the service and run it.  Running connections will
 // continue to operate after Register returns (even with non-nil error).
 // Call Close to shutdown all running connections.
+//
+// With WithReconnect, Register only returns when ctx is done, the
+// ServiceClient is closed, or registration fails with an error which can't be
+// fixed by retrying.
 func (sc *ServiceClient) Register(ctx context.Context) error {
+	attempt := 0
+	for {
+		sc.setState(StateConnecting, nil)
+		registered, err := sc.register(ctx)
+		if registered {
+			attempt = 0
+		}
+
+		b := sc.opts.reconnect
+		if b == nil || ctx.Err() != nil || sc.isClosed() || !retryable(err) {
+			sc.setState(StateClosed, err)
+			return err
+		}
+
+		d := b.delay(attempt)
+		attempt++
+		sc.setState(StateDisconnected, err)
+		log.Printf("Service %q disconnected (retrying in %v): %v", sc.name, d, err)
+
+		select {
+		case <-time.After(d):
+		case <-ctx.Done():
+			sc.setState(StateClosed, ctx.Err())
+			return ctx.Err()
+		case <-sc.done:
+			sc.setState(StateClosed, nil)
+			return nil
+		}
+	}
+}
+
+// register the service and handle forwards until the registration ends.
+// Returns true if the service was registered.
+func (sc *ServiceClient) register(ctx context.Context) (bool, error) {
 	log.Printf("Creating service %q forwarding to %q...", sc.name, sc.target)
 	msc := pb.NewControlServiceClient(sc.cc)
 
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	network, _ := splitAddr(sc.target)
 	csc, err := msc.CreateService(ctx, &pb.CreateServiceRequest{
-		Name: sc.name,
+		Name:          sc.name,
+		Allow:         sc.opts.allow,
+		LoadBalancing: sc.opts.balancing,
+		Protocol:      protocol(network),
+		PublicKey:     sc.opts.publicKey(),
 	})
 	if err != nil {
-		return fmt.Errorf("could not create service: %w", err)
+		return false, fmt.Errorf("could not create service: %w", err)
+	}
+
+	// The router sends headers once the service has been created.
+	md, err := csc.Header()
+	if err == nil && len(md) == 0 {
+		// Stream ended without headers (a trailers-only response has
+		// empty headers), the error is returned by Recv.
+		_, err = csc.Recv()
+	}
+	if err != nil {
+		return false, fmt.Errorf("could not create service: %w", err)
 	}
+	sc.setState(StateRegistered, nil)
 
 	for {
 		resp, err := csc.Recv()
 		if err != nil {
 			if err == io.EOF {
 				// Ended peacefully!
-				return nil
+				return true, nil
 			}
-			return fmt.Errorf("could not receive: %v", err)
+			return true, fmt.Errorf("could not receive: %w", err)
 		}
-		go sc.handleConn(resp.GetToken(), resp.GetDialAddr())
+		go sc.handleConn(resp)
 	}
 }
 
-func (sc *ServiceClient) handleConn(token, dialAddr string) {
-	log.Printf("Creating connection to host traffic for forward %q", token)
+func (sc *ServiceClient) setState(s State, err error) {
+	if sc.opts.stateFunc != nil {
+		sc.opts.stateFunc(s, err)
+	}
+}
+
+func (sc *ServiceClient) isClosed() bool {
+	return isDone(sc.done)
+}
+
+func (sc *ServiceClient) handleConn(resp *pb.CreateServiceResponse) {
+	token := resp.GetToken()
+	log.Printf("Creating connection to host traffic for forward %q (from %v)", token, newAddr(sc.name, resp.GetPeer()))
 	// c, err := internal.DialPlex("tcp", dialAddr, 'p')
-	c, err := protoproxy.Dial(sc.cc)
+	pc, err := sc.pd.dial(token)
 	if err != nil {
-		log.Printf("Could not dial proxy: %v", err)
+		log.Printf("Could not create proxy connection: %v", err)
 		return
 	}
-	defer c.Close()
-
-	defer log.Printf("Closing connection hosting traffic for forward %q", token)
+	defer pc.Close()
+	pc.SetAddr(&Addr{Service: sc.name}, newAddr(sc.name, resp.GetPeer()))
 
-	if err := internal.WriteHeader(c, &pb.Header{Token: token}); err != nil {
-		fmt.Printf("Could not write header: %v", err)
+	var c net.Conn = pc
+	if sc.opts.e2eKey != nil {
+		c, err = e2eServer(pc, sc.opts.e2eKey, resp.GetPeer().GetPublicKey())
+		if err != nil {
+			log.Printf("Could not secure connection for forward %q: %v", token, err)
+			return
+		}
+		defer c.Close()
 	}
 
-	fconn, err := net.Dial("tcp", sc.target)
+	defer log.Printf("Closing connection hosting traffic for forward %q", token)
+
+	network, addr := splitAddr(sc.target)
+	fconn, err := net.Dial(network, addr)
 	if err != nil {
 		fmt.Printf("Could not dial %q for incoming connection: %v", sc.target, err)
 		return
 	}
 	defer fconn.Close()
 
+	if network == "udp" {
+		if err := relayDatagrams(fconn, &datagramConn{c}, sc.opts.idleTimeout, sc.done); err != nil {
+			log.Printf("Forwarding ended: %v", err)
+		}
+		return
+	}
+
+	if v := sc.opts.proxyProtocol; v != 0 {
+		// Addresses which can't be parsed result in a header without addresses.
+		src, _ := net.ResolveTCPAddr("tcp", resp.GetPeer().GetAddr())
+		if err := internal.WriteProxyHeader(fconn, v, src, fconn.RemoteAddr()); err != nil {
+			log.Printf("Could not write PROXY protocol header: %v", err)
+			return
+		}
+	}
+
 	if err := copyUpDown(fconn, c, sc.done); err != nil {
 		log.Printf("Forwarding ended: %v", err)
 	}
@@ -97,25 +201,29 @@ func (sc *ServiceClient) handleConn(token, dialAddr string) {
 func (sc *ServiceClient) Close() error {
 	sc.doneOnce.Do(func() {
 		close(sc.done)
+		sc.pd.close()
 	})
 	return nil
 }
 
 // NewForwardClient creates a new forward for service, that will forward connections
-// from localAddr.
-func NewForwardClient(cc *grpc.ClientConn, service, localAddr string) *ForwardClient {
+// from localAddr.  Use "udp:host:port" for localAddr to forward datagrams to a
+// UDP service, or "unix:path" to listen on a unix socket.
+func NewForwardClient(cc *grpc.ClientConn, service, localAddr string, opts ...Option) *ForwardClient {
 	return &ForwardClient{
-		cc:        cc,
+		d:         NewDialer(cc, opts...),
+		opts:      newOptions(opts),
 		service:   service,
 		localAddr: localAddr,
 		done:      make(chan bool),
 	}
 }
 
-// ForwardClient sets up a local TCP listener which takes incoming connections
-// and forwards them to the service.
+// ForwardClient sets up a local TCP or unix listener (or UDP socket) which takes
+// incoming connections and forwards them to the service.
 type ForwardClient struct {
-	cc *grpc.ClientConn
+	d    *Dialer
+	opts *options
 
 	service   string
 	localAddr string
@@ -125,16 +233,30 @@ type ForwardClient struct {
 }
 
 // Forward sets up a local listener and forward the connections to the service.
+// Returns nil when the ForwardClient is closed.
 func (fc *ForwardClient) Forward() error {
-	l, err := net.Listen("tcp", fc.localAddr)
+	network, addr := splitAddr(fc.localAddr)
+	if network == "udp" {
+		return fc.forwardDatagrams(addr)
+	}
+
+	if network == "unix" {
+		removeStaleSocket(addr)
+	}
+
+	l, err := net.Listen(network, addr)
 	if err != nil {
 		return fmt.Errorf("could not listen for incoming connections: %v", err)
 	}
 	defer l.Close()
+	go fc.closeOnDone(l)
 
 	for {
 		c, err := l.Accept()
 		if err != nil {
+			if fc.isClosed() {
+				return nil
+			}
 			return fmt.Errorf("could not accept incoming connection: %v", err)
 		}
 
@@ -148,59 +270,198 @@ func (fc *ForwardClient) handleConn(c net.Conn) {
 	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
 	defer cancel()
 
-	resp, err := pb.NewControlServiceClient(fc.cc).ForwardToService(ctx, &pb.ForwardToServiceRequest{
-		Name: fc.service,
-	})
+	fconn, err := fc.d.DialContext(ctx, "tcp", fc.service)
 	if err != nil {
-		log.Printf("Could not forward to service: %v", err)
+		log.Printf("Could not dial service %q: %v", fc.service, err)
 		return
 	}
+	defer fconn.Close()
 
-	log.Printf("Creating connection to host forward %q", resp.GetToken())
+	log.Printf("Created connection for forward to %q", fc.service)
+	defer log.Printf("Closing connection for forward to %q", fc.service)
 
-	// Dial the proxy.
-	// fconn, err := internal.DialPlex("tcp", resp.GetDialAddr(), 'p')
-	fconn, err := protoproxy.Dial(fc.cc)
-	if err != nil {
-		log.Printf("Could not dial: %v", err)
+	if err := copyUpDown(fconn, c, fc.done); err != nil {
+		log.Printf("Forwarding ended: %v", err)
 	}
-	defer fconn.Close()
+}
 
-	defer log.Printf("Closing connection for hosted forward %q", resp.GetToken())
+// closeOnDone closes c when the ForwardClient is closed.
+func (fc *ForwardClient) closeOnDone(c io.Closer) {
+	<-fc.done
+	c.Close()
+}
 
-	// Identify this forward.
-	if err := internal.WriteHeader(fconn, &pb.Header{Token: resp.GetToken()}); err != nil {
-		log.Printf("Could not write header: %v", err)
+func (fc *ForwardClient) isClosed() bool {
+	return isDone(fc.done)
+}
+
+// removeStaleSocket removes the unix socket at path if connections to it are
+// refused (i.e. it was left behind by a previous process).  Anything else at
+// path is left alone.
+func removeStaleSocket(path string) {
+	fi, err := os.Lstat(path)
+	if err != nil || fi.Mode()&os.ModeSocket == 0 {
 		return
 	}
-
-	if err := copyUpDown(fconn, c, fc.done); err != nil {
-		log.Printf("Forwarding ended: %v", err)
+	c, err := net.Dial("unix", path)
+	if err == nil {
+		c.Close()
+		return
+	}
+	if errors.Is(err, syscall.ECONNREFUSED) {
+		os.Remove(path)
 	}
 }
 
-// Close shuts down all running connections.
+// Close shuts down the listener and all running connections.
 func (fc *ForwardClient) Close() error {
 	fc.doneOnce.Do(func() {
 		close(fc.done)
+		fc.d.Close()
 	})
 	return nil
 }
 
+func newProxyDialer(cc *grpc.ClientConn, multiplex bool) *proxyDialer {
+	return &proxyDialer{
+		cc:        cc,
+		multiplex: multiplex,
+	}
+}
+
+// proxyDialer creates proxy connections, optionally multiplexing them over a
+// single (lazily created) session.
+type proxyDialer struct {
+	cc        *grpc.ClientConn
+	multiplex bool
+
+	mu      sync.Mutex // protects session
+	session *protoproxy.Session
+}
+
+// dial creates a new proxy connection and identifies it to the router
+// using token.
+func (d *proxyDialer) dial(token string) (*protoproxy.Conn, error) {
+	c, err := d.open()
+	if err != nil {
+		return nil, fmt.Errorf("could not dial proxy: %w", err)
+	}
+
+	if err := internal.WriteHeader(c, &pb.Header{Token: token}); err != nil {
+		c.Close()
+		return nil, fmt.Errorf("could not write header: %w", err)
+	}
+	return c, nil
+}
+
+func (d *proxyDialer) open() (*protoproxy.Conn, error) {
+	if !d.multiplex {
+		return protoproxy.Dial(d.cc)
+	}
+
+	defer d.mu.Unlock()
+	d.mu.Lock()
+
+	if d.session != nil {
+		select {
+		case <-d.session.Done():
+			d.session = nil
+		default:
+		}
+	}
+
+	if d.session == nil {
+		s, err := protoproxy.DialSession(d.cc)
+		if err != nil {
+			return nil, err
+		}
+		d.session = s
+	}
+	return d.session.Open()
+}
+
+// close the session (if any), which closes all multiplexed connections.
+func (d *proxyDialer) close() {
+	defer d.mu.Unlock()
+	d.mu.Lock()
+
+	if d.session != nil {
+		d.session.Close()
+		d.session = nil
+	}
+}
+
+// closeWriter is implemented by connections which support half-close (i.e.
+// *net.TCPConn and *protoproxy.Conn).
+type closeWriter interface {
+	CloseWrite() error
+}
+
+// lingerTimeout is how long copyUpDown waits for the remaining direction of
+// a half-closed connection while no data is sent.
+const lingerTimeout = time.Minute
+
+// errLinger is returned by copyUpDown when the remaining direction of a
+// half-closed connection is idle for lingerTimeout.
+var errLinger = errors.New("timed out waiting for half-closed connection to finish")
+
+// copyUpDown copies data between up and down until both directions have
+// finished, an error occurs, or done is closed.  When a direction finishes
+// the writing side of its destination is closed (if supported) so that
+// half-closed connections are propagated, and the other direction has
+// lingerTimeout of inactivity in which to finish.
 func copyUpDown(up, down io.ReadWriter, done <-chan bool) error {
-	errc := make(chan error, 1)
-	go cp(up, down, errc)
-	go cp(down, up, errc)
+	var last int64 // time of the last read (in either direction), accessed atomically
+	errc := make(chan error, 2)
+	go cp(up, &activityReader{down, &last}, errc)
+	go cp(down, &activityReader{up, &last}, errc)
+
+	var linger *time.Timer
+	var lingerC <-chan time.Time
+	for i := 0; i < 2; {
+		select {
+		case err := <-errc:
+			if err != nil {
+				return err
+			}
+			i++
+			if linger == nil {
+				linger = time.NewTimer(lingerTimeout)
+				defer linger.Stop()
+				lingerC = linger.C
+			}
+
+		case <-lingerC:
+			idle := time.Since(time.Unix(0, atomic.LoadInt64(&last)))
+			if idle >= lingerTimeout {
+				return errLinger
+			}
+			linger.Reset(lingerTimeout - idle)
 
-	select {
-	case err := <-errc:
-		return err
-	case <-done:
+		case <-done:
+			return nil
+		}
 	}
 	return nil
 }
 
+// activityReader records the time of each read in last (accessed
+// atomically).
+type activityReader struct {
+	io.Reader
+	last *int64
+}
+
+func (r *activityReader) Read(b []byte) (int, error) {
+	n, err := r.Reader.Read(b)
+	atomic.StoreInt64(r.last, time.Now().UnixNano())
+	return n, err
+}
+
 func cp(w io.Writer, r io.Reader, errCh chan error) {
 	_, err := io.Copy(w, r)
+	if cw, ok := w.(closeWriter); ok {
+		cw.CloseWrite()
+	}
 	errCh <- err
 }
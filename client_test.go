@@ -0,0 +1,114 @@
+package mindmeld_test
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"testing"
+
+	"github.com/dhowden/mindmeld"
+)
+
+// countServer accepts connections from l, reads until EOF and then writes
+// back the number of bytes read.
+func countServer(l net.Listener) {
+	for {
+		c, err := l.Accept()
+		if err != nil {
+			return
+		}
+		go func() {
+			defer c.Close()
+			n, _ := io.Copy(ioutil.Discard, c)
+			fmt.Fprintf(c, "%d", n)
+		}()
+	}
+}
+
+func TestServiceClientHalfClose(t *testing.T) {
+	const msg = "hello world!"
+
+	r := NewTestRouter(t)
+	cc := r.ClientConn(t)
+
+	l, err := net.Listen("tcp", "localhost:0")
+	if err != nil {
+		t.Fatalf("could not listen: %v", err)
+	}
+	defer l.Close()
+	go countServer(l)
+
+	sc := mindmeld.NewServiceClient(cc, "count", l.Addr().String())
+	defer sc.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go sc.Register(ctx)
+
+	waitForService(t, cc, "count")
+
+	c, err := mindmeld.NewDialer(cc).DialContext(context.Background(), "tcp", "count")
+	if err != nil {
+		t.Fatalf("DialContext() = %v", err)
+	}
+	defer c.Close()
+
+	if _, err := io.WriteString(c, msg); err != nil {
+		t.Fatalf("write failed: %v", err)
+	}
+	c.(interface{ CloseWrite() error }).CloseWrite()
+
+	got, err := ioutil.ReadAll(c)
+	if err != nil {
+		t.Fatalf("read failed: %v", err)
+	}
+	if want := fmt.Sprintf("%d", len(msg)); string(got) != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestServiceClientProxyProtocol(t *testing.T) {
+	r := NewTestRouter(t)
+	cc := r.ClientConn(t)
+
+	l, err := net.Listen("tcp", "localhost:0")
+	if err != nil {
+		t.Fatalf("could not listen: %v", err)
+	}
+	defer l.Close()
+
+	got := make(chan string, 1)
+	go func() {
+		c, err := l.Accept()
+		if err != nil {
+			return
+		}
+		defer c.Close()
+
+		line, _ := bufio.NewReader(c).ReadString('\n')
+		got <- line
+	}()
+
+	sc := mindmeld.NewServiceClient(cc, "svc", l.Addr().String(), mindmeld.WithProxyProtocol(1))
+	defer sc.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go sc.Register(ctx)
+
+	waitForService(t, cc, "svc")
+
+	c, err := mindmeld.NewDialer(cc).DialContext(context.Background(), "tcp", "svc")
+	if err != nil {
+		t.Fatalf("DialContext() = %v", err)
+	}
+	defer c.Close()
+
+	// The in-memory test router has no IP address for the forwarder.
+	if line := <-got; line != "PROXY UNKNOWN\r\n" {
+		t.Errorf("target read %q, want PROXY protocol header", line)
+	}
+}
@@ -0,0 +1,494 @@
+package mindmeld
+
+import (
+	"context"
+	crand "crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"math/rand"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+
+	"github.com/dhowden/mindmeld/internal"
+	"github.com/dhowden/mindmeld/internal/protoproxy"
+	"github.com/dhowden/mindmeld/pb"
+)
+
+// Cluster is the membership of a cluster of routers, and records which
+// routers host each service.  Implementations must be safe for concurrent
+// use (and are typically backed by a shared store, see NewDirCluster).
+//
+// Membership expires: routers renew it by calling Join periodically, and a
+// router which hasn't done so within the TTL of the Cluster (i.e. it crashed
+// without calling Leave) is treated as having left, along with its services.
+type Cluster interface {
+	// Join adds the router id, reachable at addr, to the cluster (or renews
+	// its membership).
+	Join(id, addr string) error
+
+	// Leave removes the router id (and its services) from the cluster.
+	Leave(id string) error
+
+	// Addr returns the address of the router id.
+	Addr(id string) (string, error)
+
+	// AddService records that the router id hosts the service name, owned by
+	// owner.  Returns ErrServiceOwned if the service is hosted by another
+	// (live) router with a different owner.
+	AddService(name, owner, id string) error
+
+	// RemoveService records that the router id no longer hosts the service
+	// name.
+	RemoveService(name, id string) error
+
+	// Routers returns the live routers which host the service name.
+	Routers(name string) ([]string, error)
+}
+
+// DefaultClusterTTL is the default time after which routers which haven't
+// renewed their membership of a Cluster are treated as having left.
+const DefaultClusterTTL = 30 * time.Second
+
+// DefaultClusterHeartbeat is the default interval at which routers renew their
+// membership of a Cluster (see ClusterConfig).
+const DefaultClusterHeartbeat = 10 * time.Second
+
+// ErrServiceOwned is returned by Cluster.AddService when a service is owned
+// by someone else.
+var ErrServiceOwned = errors.New("service owned by another identity")
+
+// NewLocalCluster creates a Cluster for routers in the same process (i.e. for
+// tests), in which membership expires after ttl.
+func NewLocalCluster(ttl time.Duration) Cluster {
+	return &localCluster{
+		ttl:      ttl,
+		routers:  make(map[string]*clusterRouter),
+		services: make(map[string]*clusterService),
+	}
+}
+
+type localCluster struct {
+	ttl time.Duration
+
+	mu       sync.Mutex
+	routers  map[string]*clusterRouter  // router id -> router
+	services map[string]*clusterService // name -> service
+}
+
+type clusterRouter struct {
+	addr string
+	seen time.Time // last Join
+}
+
+type clusterService struct {
+	owner   string
+	routers map[string]bool
+}
+
+// live returns true if the router id has renewed its membership within the
+// TTL.  Must be called with mu held.
+func (c *localCluster) live(id string) bool {
+	r, ok := c.routers[id]
+	return ok && time.Since(r.seen) < c.ttl
+}
+
+func (c *localCluster) Join(id, addr string) error {
+	defer c.mu.Unlock()
+	c.mu.Lock()
+
+	c.routers[id] = &clusterRouter{
+		addr: addr,
+		seen: time.Now(),
+	}
+	return nil
+}
+
+func (c *localCluster) Leave(id string) error {
+	defer c.mu.Unlock()
+	c.mu.Lock()
+
+	delete(c.routers, id)
+	for name, svc := range c.services {
+		delete(svc.routers, id)
+		if len(svc.routers) == 0 {
+			delete(c.services, name)
+		}
+	}
+	return nil
+}
+
+func (c *localCluster) Addr(id string) (string, error) {
+	defer c.mu.Unlock()
+	c.mu.Lock()
+
+	if !c.live(id) {
+		return "", fmt.Errorf("unknown router %q", id)
+	}
+	return c.routers[id].addr, nil
+}
+
+func (c *localCluster) AddService(name, owner, id string) error {
+	defer c.mu.Unlock()
+	c.mu.Lock()
+
+	svc, ok := c.services[name]
+	if ok {
+		// Services of routers which have expired are released.
+		for r := range svc.routers {
+			if r != id && !c.live(r) {
+				delete(svc.routers, r)
+			}
+		}
+		if len(svc.routers) == 0 {
+			ok = false
+		}
+	}
+	if !ok {
+		svc = &clusterService{
+			owner:   owner,
+			routers: make(map[string]bool),
+		}
+		c.services[name] = svc
+	}
+	if svc.owner != owner {
+		return ErrServiceOwned
+	}
+	svc.routers[id] = true
+	return nil
+}
+
+func (c *localCluster) RemoveService(name, id string) error {
+	defer c.mu.Unlock()
+	c.mu.Lock()
+
+	if svc, ok := c.services[name]; ok {
+		delete(svc.routers, id)
+		if len(svc.routers) == 0 {
+			delete(c.services, name)
+		}
+	}
+	return nil
+}
+
+func (c *localCluster) Routers(name string) ([]string, error) {
+	defer c.mu.Unlock()
+	c.mu.Lock()
+
+	svc, ok := c.services[name]
+	if !ok {
+		return nil, nil
+	}
+	out := make([]string, 0, len(svc.routers))
+	for id := range svc.routers {
+		if c.live(id) {
+			out = append(out, id)
+		}
+	}
+	return out, nil
+}
+
+// ClusterConfig configures a Server as a member of a cluster of routers.
+//
+// Forwards to services hosted by other routers are relayed: ForwardToService
+// (and DialService, used by HTTPProxy) is passed to the hosting router, and
+// proxy connections are passed to the router which issued their token.
+// Routers must be able to reach each other on their gRPC (control and proxy)
+// services.  SNIProxy only reaches services hosted by its own router.
+type ClusterConfig struct {
+	// Cluster shared by the routers.
+	Cluster Cluster
+
+	// ID of the router, which must be unique in the cluster, and not contain
+	// ".".  If empty, a random ID is used.
+	ID string
+
+	// Addr is the address other routers use to reach the router.
+	Addr string
+
+	// Secret shared by the routers, which authenticates relayed requests.
+	// Must not be empty.
+	Secret string
+
+	// Dial creates a connection to the router at addr.  Defaults to an
+	// insecure gRPC connection.
+	Dial func(ctx context.Context, addr string) (*grpc.ClientConn, error)
+
+	// Heartbeat is the interval at which the router renews its membership of
+	// the Cluster, which must be less than the Cluster's TTL.  Defaults to
+	// DefaultClusterHeartbeat.
+	Heartbeat time.Duration
+}
+
+// WithCluster makes the Server a member of a cluster of routers.
+func WithCluster(cfg ClusterConfig) ServerOption {
+	if cfg.ID == "" {
+		b := make([]byte, 8)
+		crand.Read(b)
+		cfg.ID = hex.EncodeToString(b)
+	}
+	if cfg.Dial == nil {
+		cfg.Dial = func(ctx context.Context, addr string) (*grpc.ClientConn, error) {
+			return grpc.DialContext(ctx, addr, grpc.WithInsecure())
+		}
+	}
+	if cfg.Heartbeat <= 0 {
+		cfg.Heartbeat = DefaultClusterHeartbeat
+	}
+	return func(s *Server) {
+		s.cluster = &clusterMember{
+			ClusterConfig: cfg,
+			conns:         make(map[string]*grpc.ClientConn),
+		}
+	}
+}
+
+// clusterMember is the Server's view of the cluster.
+type clusterMember struct {
+	ClusterConfig
+
+	mu    sync.Mutex // protects conns
+	conns map[string]*grpc.ClientConn
+}
+
+// conn returns a connection to the router id.
+func (m *clusterMember) conn(ctx context.Context, id string) (*grpc.ClientConn, error) {
+	defer m.mu.Unlock()
+	m.mu.Lock()
+
+	if cc, ok := m.conns[id]; ok {
+		return cc, nil
+	}
+
+	addr, err := m.Cluster.Addr(id)
+	if err != nil {
+		return nil, err
+	}
+	cc, err := m.Dial(ctx, addr)
+	if err != nil {
+		return nil, fmt.Errorf("could not dial router %q: %w", id, err)
+	}
+	m.conns[id] = cc
+	return cc, nil
+}
+
+func (m *clusterMember) close() {
+	defer m.mu.Unlock()
+	m.mu.Lock()
+
+	for id, cc := range m.conns {
+		cc.Close()
+		delete(m.conns, id)
+	}
+}
+
+// Metadata keys used to relay requests between routers.
+const (
+	relaySecretKey   = "mindmeld-relay-secret"
+	relayIdentityKey = "mindmeld-relay-identity"
+	relayGroupsKey   = "mindmeld-relay-groups"
+	relayAddrKey     = "mindmeld-relay-addr"
+)
+
+// relayedIdentity is the context key for the identity of a relayed caller.
+type relayedIdentity struct{}
+
+// splitToken splits token into the router which issued it ("" if the token
+// isn't from a cluster member) and the token issued by the router.
+func splitToken(token string) (router, t string) {
+	i := strings.LastIndex(token, ".")
+	if i < 0 {
+		return "", token
+	}
+	return token[:i], token[i+1:]
+}
+
+// joinCluster adds the Server to its cluster (if any), and renews its
+// membership until the Server is closed.
+func (s *Server) joinCluster() {
+	if s.cluster == nil {
+		return
+	}
+	if err := s.cluster.Cluster.Join(s.cluster.ID, s.cluster.Addr); err != nil {
+		log.Printf("Could not join cluster: %v", err)
+	}
+
+	go func() {
+		t := time.NewTicker(s.cluster.Heartbeat)
+		defer t.Stop()
+
+		for {
+			select {
+			case <-t.C:
+				if err := s.cluster.Cluster.Join(s.cluster.ID, s.cluster.Addr); err != nil {
+					log.Printf("Could not renew cluster membership: %v", err)
+				}
+			case <-s.done:
+				return
+			}
+		}
+	}()
+}
+
+// leaveCluster removes the Server from its cluster (if any).
+func (s *Server) leaveCluster() {
+	if s.cluster == nil {
+		return
+	}
+	if err := s.cluster.Cluster.Leave(s.cluster.ID); err != nil {
+		log.Printf("Could not leave cluster: %v", err)
+	}
+	s.cluster.close()
+}
+
+// relayForward passes a ForwardToService request for a service which isn't
+// hosted by this router to a router which hosts it.  Routers which can't be
+// reached are skipped.  Returns a NotFound error if no router hosts the
+// service.
+func (s *Server) relayForward(ctx context.Context, r *pb.ForwardToServiceRequest) (*pb.ForwardToServiceResponse, error) {
+	name := r.GetName()
+	var routers []string
+	if s.cluster != nil {
+		all, err := s.cluster.Cluster.Routers(name)
+		if err != nil {
+			return nil, status.Errorf(codes.Unavailable, "could not lookup service %q: %v", name, err)
+		}
+		for _, id := range all {
+			if id != s.cluster.ID {
+				routers = append(routers, id)
+			}
+		}
+	}
+	if len(routers) == 0 {
+		return nil, status.Errorf(codes.NotFound, "service %q does not exist", name)
+	}
+
+	// Relayed requests are authenticated by the router which receives them
+	// (but authorized by the hosting router).
+	id, err := s.authenticate(ctx)
+	if err != nil {
+		return nil, err
+	}
+	md := metadata.Pairs(
+		relaySecretKey, s.cluster.Secret,
+		relayIdentityKey, id.Name,
+		relayGroupsKey, strings.Join(id.Groups, ","),
+	)
+	if addr := peerAddr(ctx); addr != nil {
+		md.Set(relayAddrKey, addr.String())
+	}
+
+	ctx = metadata.NewOutgoingContext(ctx, md)
+	for _, i := range rand.Perm(len(routers)) {
+		router := routers[i]
+		cc, err := s.cluster.conn(ctx, router)
+		if err != nil {
+			log.Printf("Could not relay forward to router %q: %v", router, err)
+			continue
+		}
+
+		log.Printf("Relaying forward to service %q (caller: %v) to router %q", name, id, router)
+		resp, err := pb.NewControlServiceClient(cc).ForwardToService(ctx, r)
+		if status.Code(err) == codes.Unavailable {
+			log.Printf("Could not relay forward to router %q: %v", router, err)
+			continue
+		}
+		return resp, err
+	}
+	return nil, status.Errorf(codes.Unavailable, "could not relay forward to service %q: no router reachable", name)
+}
+
+// dialRelayed creates a connection to the service name hosted by another
+// router, using a relayed forward and a proxy connection to that router.
+func (s *Server) dialRelayed(ctx context.Context, name string) (net.Conn, error) {
+	resp, err := s.relayForward(ctx, &pb.ForwardToServiceRequest{
+		Name:     name,
+		Protocol: pb.Protocol_TCP,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	router, _ := splitToken(resp.GetToken())
+	cc, err := s.cluster.conn(ctx, router)
+	if err != nil {
+		return nil, status.Errorf(codes.Unavailable, "could not dial router %q: %v", router, err)
+	}
+	c, err := protoproxy.Dial(cc)
+	if err != nil {
+		return nil, status.Errorf(codes.Unavailable, "could not create proxy connection to router %q: %v", router, err)
+	}
+	if err := internal.WriteHeader(c, &pb.Header{Token: resp.GetToken()}); err != nil {
+		c.Close()
+		return nil, status.Errorf(codes.Unavailable, "could not write header to router %q: %v", router, err)
+	}
+	return c, nil
+}
+
+// fromRelay returns ctx with the identity and address of the caller of a
+// request relayed by another router in the cluster (if it is one).
+func (s *Server) fromRelay(ctx context.Context) (context.Context, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	secrets := md.Get(relaySecretKey)
+	if len(secrets) == 0 {
+		return ctx, nil
+	}
+	if s.cluster == nil || s.cluster.Secret == "" || subtle.ConstantTimeCompare([]byte(secrets[0]), []byte(s.cluster.Secret)) != 1 {
+		return nil, status.Errorf(codes.Unauthenticated, "invalid relay secret")
+	}
+
+	id := &Identity{}
+	if v := md.Get(relayIdentityKey); len(v) > 0 {
+		id.Name = v[0]
+	}
+	if v := md.Get(relayGroupsKey); len(v) > 0 && v[0] != "" {
+		id.Groups = strings.Split(v[0], ",")
+	}
+	ctx = context.WithValue(ctx, relayedIdentity{}, id)
+
+	p := &peer.Peer{}
+	if v := md.Get(relayAddrKey); len(v) > 0 {
+		if addr, err := net.ResolveTCPAddr("tcp", v[0]); err == nil {
+			p.Addr = addr
+		}
+	}
+	return peer.NewContext(ctx, p), nil
+}
+
+// relayProxyConn passes the proxy connection c (with header h) to the router
+// which issued its token.
+func (s *Server) relayProxyConn(router string, h *pb.Header, c net.Conn) {
+	defer c.Close()
+
+	cc, err := s.cluster.conn(context.Background(), router)
+	if err != nil {
+		log.Printf("Could not relay proxy connection: %v", err)
+		return
+	}
+
+	rc, err := protoproxy.Dial(cc)
+	if err != nil {
+		log.Printf("Could not relay proxy connection to router %q: %v", router, err)
+		return
+	}
+	defer rc.Close()
+
+	if err := internal.WriteHeader(rc, h); err != nil {
+		log.Printf("Could not relay proxy connection to router %q: %v", router, err)
+		return
+	}
+
+	if err := copyUpDown(rc, c, s.done); err != nil {
+		log.Printf("Relayed proxy connection ended: %v", err)
+	}
+}
@@ -0,0 +1,282 @@
+package mindmeld_test
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"reflect"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/dhowden/mindmeld"
+	"github.com/dhowden/mindmeld/pb"
+)
+
+// newTestCluster creates n routers in the cluster c.  Routers are dialed by
+// their ID, and addresses of routers which don't exist can't be reached.
+func newTestCluster(t *testing.T, c mindmeld.Cluster, n int, opts ...mindmeld.ServerOption) []*TestRouter {
+	var mu sync.Mutex
+	routers := make(map[string]*TestRouter)
+	dial := func(ctx context.Context, addr string) (*grpc.ClientConn, error) {
+		return grpc.DialContext(ctx, "bufconn",
+			grpc.WithContextDialer(func(context.Context, string) (net.Conn, error) {
+				mu.Lock()
+				r, ok := routers[addr]
+				mu.Unlock()
+				if !ok {
+					return nil, fmt.Errorf("no router at %q", addr)
+				}
+				return r.l.Dial()
+			}),
+			grpc.WithInsecure(),
+		)
+	}
+
+	out := make([]*TestRouter, n)
+	for i := range out {
+		id := string(rune('a' + i))
+		out[i] = NewTestRouter(t, append(opts, mindmeld.WithCluster(mindmeld.ClusterConfig{
+			Cluster:   c,
+			ID:        id,
+			Addr:      id,
+			Secret:    "secret",
+			Dial:      dial,
+			Heartbeat: 10 * time.Millisecond,
+		}))...)
+		mu.Lock()
+		routers[id] = out[i]
+		mu.Unlock()
+	}
+	return out
+}
+
+func TestCluster(t *testing.T) {
+	rs := newTestCluster(t, mindmeld.NewLocalCluster(time.Minute), 2)
+	ccA, ccB := rs[0].ClientConn(t), rs[1].ClientConn(t)
+
+	l, err := mindmeld.Listen(context.Background(), ccB, "svc")
+	if err != nil {
+		t.Fatalf("Listen() = %v", err)
+	}
+	defer l.Close()
+	go serveName(l, "svc")
+	waitForService(t, ccB, "svc")
+
+	for i := 0; i < 3; i++ {
+		if got := dialRead(t, ccA, "svc"); got != "svc" {
+			t.Errorf("dialRead() = %q, want %q", got, "svc")
+		}
+	}
+
+	_, err = mindmeld.NewDialer(ccA).DialContext(context.Background(), "tcp", "missing")
+	if status.Code(err) != codes.NotFound {
+		t.Errorf("DialContext() = %v, want code %v", err, codes.NotFound)
+	}
+}
+
+func TestClusterAccessList(t *testing.T) {
+	rs := newTestCluster(t, mindmeld.NewLocalCluster(time.Minute), 2, mindmeld.WithAuthenticator(mindmeld.NewTokenAuthenticator(map[string]*mindmeld.Identity{
+		"alice": {Name: "alice"},
+		"bob":   {Name: "bob"},
+		"carol": {Name: "carol"},
+	})))
+	allow, err := mindmeld.ParseAccessList([]string{"bob"})
+	if err != nil {
+		t.Fatalf("ParseAccessList() = %v", err)
+	}
+
+	cc := tokenConn(t, rs[1], "alice")
+	l, err := mindmeld.Listen(context.Background(), cc, "db", mindmeld.WithAccessList(allow))
+	if err != nil {
+		t.Fatalf("Listen() = %v", err)
+	}
+	defer l.Close()
+	waitForService(t, cc, "db")
+
+	tests := []struct {
+		token string
+		code  codes.Code
+	}{
+		{"alice", codes.OK},
+		{"bob", codes.OK},
+		{"carol", codes.NotFound},
+		{"mallory", codes.Unauthenticated},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.token, func(t *testing.T) {
+			_, err := pb.NewControlServiceClient(tokenConn(t, rs[0], tt.token)).ForwardToService(context.Background(), &pb.ForwardToServiceRequest{
+				Name: "db",
+			})
+			if status.Code(err) != tt.code {
+				t.Errorf("ForwardToService() = %v, want code %v", err, tt.code)
+			}
+		})
+	}
+
+	// Names are reserved for their owner across the cluster.
+	csc, err := pb.NewControlServiceClient(tokenConn(t, rs[0], "carol")).CreateService(context.Background(), &pb.CreateServiceRequest{
+		Name: "db",
+	})
+	if err != nil {
+		t.Fatalf("CreateService() = %v", err)
+	}
+	if _, err := csc.Recv(); status.Code(err) != codes.AlreadyExists {
+		t.Errorf("Recv() = %v, want code %v", err, codes.AlreadyExists)
+	}
+}
+
+func TestClusterUnreachableRouter(t *testing.T) {
+	c := mindmeld.NewLocalCluster(time.Minute)
+	rs := newTestCluster(t, c, 2)
+	ccA, ccB := rs[0].ClientConn(t), rs[1].ClientConn(t)
+
+	l, err := mindmeld.Listen(context.Background(), ccB, "svc")
+	if err != nil {
+		t.Fatalf("Listen() = %v", err)
+	}
+	defer l.Close()
+	go serveName(l, "svc")
+	waitForService(t, ccB, "svc")
+
+	// A router which can't be reached also claims to host the service.
+	c.Join("dead", "dead")
+	if err := c.AddService("svc", "", "dead"); err != nil {
+		t.Fatalf("AddService() = %v", err)
+	}
+
+	for i := 0; i < 5; i++ {
+		if got := dialRead(t, ccA, "svc"); got != "svc" {
+			t.Errorf("dialRead() = %q, want %q", got, "svc")
+		}
+	}
+}
+
+func TestClusterExpiry(t *testing.T) {
+	c := mindmeld.NewLocalCluster(100 * time.Millisecond)
+	rs := newTestCluster(t, c, 1)
+
+	// A router which crashed (without leaving the cluster) stops renewing its
+	// membership.
+	c.Join("dead", "dead")
+	if err := c.AddService("svc", "mallory", "dead"); err != nil {
+		t.Fatalf("AddService() = %v", err)
+	}
+	time.Sleep(150 * time.Millisecond)
+
+	got, err := c.Routers("svc")
+	if err != nil || len(got) != 0 {
+		t.Errorf("Routers() = %v, %v, expected no routers", got, err)
+	}
+	if _, err := c.Addr("dead"); err == nil {
+		t.Errorf("Addr() = nil, expected error for expired router")
+	}
+
+	// The service name is released, and live routers are kept.
+	cc := rs[0].ClientConn(t)
+	l, err := mindmeld.Listen(context.Background(), cc, "svc")
+	if err != nil {
+		t.Fatalf("Listen() = %v", err)
+	}
+	defer l.Close()
+	waitForService(t, cc, "svc")
+
+	time.Sleep(150 * time.Millisecond)
+	if got, err := c.Routers("svc"); err != nil || len(got) != 1 || got[0] != "a" {
+		t.Errorf("Routers() = %v, %v, expected [a]", got, err)
+	}
+}
+
+func TestClusterDialService(t *testing.T) {
+	rs := newTestCluster(t, mindmeld.NewLocalCluster(time.Minute), 2)
+	ccB := rs[1].ClientConn(t)
+
+	l, err := mindmeld.Listen(context.Background(), ccB, "svc")
+	if err != nil {
+		t.Fatalf("Listen() = %v", err)
+	}
+	defer l.Close()
+	go serveName(l, "svc")
+	waitForService(t, ccB, "svc")
+
+	// DialService (used by HTTPProxy) relays to the hosting router.
+	c, err := rs[0].DialService(context.Background(), "svc")
+	if err != nil {
+		t.Fatalf("DialService() = %v", err)
+	}
+	defer c.Close()
+
+	b, err := ioutil.ReadAll(c)
+	if err != nil {
+		t.Fatalf("could not read: %v", err)
+	}
+	if got, want := string(b), "svc"; got != want {
+		t.Errorf("read %q, expected %q", got, want)
+	}
+
+	if _, err := rs[0].DialService(context.Background(), "missing"); status.Code(err) != codes.NotFound {
+		t.Errorf("DialService() = %v, want code %v", err, codes.NotFound)
+	}
+}
+
+func TestDirCluster(t *testing.T) {
+	// Each router (in its own process) has its own DirCluster.
+	dir := t.TempDir()
+	newCluster := func() *mindmeld.DirCluster {
+		c, err := mindmeld.NewDirCluster(dir, 100*time.Millisecond)
+		if err != nil {
+			t.Fatalf("NewDirCluster() = %v", err)
+		}
+		return c
+	}
+	a, b := newCluster(), newCluster()
+
+	a.Join("a", "addr-a")
+	b.Join("b", "addr-b")
+	if got, err := b.Addr("a"); err != nil || got != "addr-a" {
+		t.Errorf("Addr() = %q, %v, expected %q", got, err, "addr-a")
+	}
+
+	if err := a.AddService("svc", "alice", "a"); err != nil {
+		t.Fatalf("AddService() = %v", err)
+	}
+	if err := b.AddService("svc", "bob", "b"); err != mindmeld.ErrServiceOwned {
+		t.Errorf("AddService() = %v, expected %v", err, mindmeld.ErrServiceOwned)
+	}
+	if err := b.AddService("svc", "alice", "b"); err != nil {
+		t.Fatalf("AddService() = %v", err)
+	}
+
+	got, err := a.Routers("svc")
+	sort.Strings(got)
+	if err != nil || !reflect.DeepEqual(got, []string{"a", "b"}) {
+		t.Errorf("Routers() = %v, %v, expected [a b]", got, err)
+	}
+
+	b.RemoveService("svc", "b")
+	if got, err := a.Routers("svc"); err != nil || !reflect.DeepEqual(got, []string{"a"}) {
+		t.Errorf("Routers() = %v, %v, expected [a]", got, err)
+	}
+
+	// a stops renewing its membership, and so its services are released.
+	time.Sleep(150 * time.Millisecond)
+	b.Join("b", "addr-b")
+	if got, err := b.Routers("svc"); err != nil || len(got) != 0 {
+		t.Errorf("Routers() = %v, %v, expected no routers", got, err)
+	}
+	if err := b.AddService("svc", "bob", "b"); err != nil {
+		t.Errorf("AddService() = %v, expected nil", err)
+	}
+
+	b.Leave("b")
+	if _, err := a.Addr("b"); err == nil {
+		t.Errorf("Addr() = nil, expected error after Leave")
+	}
+}
@@ -1,9 +1,13 @@
 package main
 
 import (
+	"fmt"
 	"log"
 	"net"
+	"net/http"
 	"os"
+	"strings"
+	"time"
 
 	"google.golang.org/grpc"
 
@@ -27,9 +31,54 @@ func main() {
 		log.Fatalf("Listen: %v", err)
 	}
 
+	var opts []mindmeld.ServerOption
+	if path := os.Getenv("AUTH_TOKENS_FILE"); path != "" {
+		log.Printf("AUTH_TOKENS_FILE: %q", path)
+		tokens, err := readTokens(path)
+		if err != nil {
+			log.Fatalf("Could not read tokens: %v", err)
+		}
+		opts = append(opts, mindmeld.WithAuthenticator(mindmeld.NewTokenAuthenticator(tokens)))
+	}
+
+	if admins := os.Getenv("ADMINS"); admins != "" {
+		log.Printf("ADMINS: %q", admins)
+		x, err := mindmeld.ParseAdmins(strings.Split(admins, ","))
+		if err != nil {
+			log.Fatalf("Invalid ADMINS: %v", err)
+		}
+		opts = append(opts, mindmeld.WithAdmins(x))
+	}
+
+	if leaseGrace := os.Getenv("LEASE_GRACE"); leaseGrace != "" {
+		log.Printf("LEASE_GRACE: %q", leaseGrace)
+		d, err := time.ParseDuration(leaseGrace)
+		if err != nil {
+			log.Fatalf("Invalid LEASE_GRACE: %v", err)
+		}
+		opts = append(opts, mindmeld.WithLeaseGrace(d))
+	}
+
+	if dir := os.Getenv("CLUSTER_DIR"); dir != "" {
+		log.Printf("CLUSTER_DIR: %q", dir)
+		cfg, err := clusterConfig(dir)
+		if err != nil {
+			log.Fatalf("Could not configure cluster: %v", err)
+		}
+		opts = append(opts, mindmeld.WithCluster(cfg))
+	}
+
 	pps := protoproxy.NewServer()
 
-	s := mindmeld.NewServer(dialAddr)
+	s := mindmeld.NewServer(dialAddr, opts...)
+
+	if metricsPort := os.Getenv("METRICS_PORT"); metricsPort != "" {
+		go func() {
+			log.Printf("Listening for metrics requests on :%v...", metricsPort)
+			log.Printf("http.ListenAndServe(): %v", http.ListenAndServe(":"+metricsPort, s.MetricsHandler()))
+		}()
+	}
+
 	go func() {
 		if err := s.ProxyListen(pps); err != nil {
 			log.Printf("Listen(): %v", err)
@@ -43,3 +92,38 @@ func main() {
 		log.Printf("gRPC.Serve(): %v", err)
 	}
 }
+
+// clusterConfig configures the router as a member of the cluster in dir,
+// using CLUSTER_ID, CLUSTER_ADDR and CLUSTER_SECRET.
+func clusterConfig(dir string) (mindmeld.ClusterConfig, error) {
+	addr := os.Getenv("CLUSTER_ADDR")
+	if addr == "" {
+		return mindmeld.ClusterConfig{}, fmt.Errorf("CLUSTER_DIR requires CLUSTER_ADDR")
+	}
+	secret := os.Getenv("CLUSTER_SECRET")
+	if secret == "" {
+		return mindmeld.ClusterConfig{}, fmt.Errorf("CLUSTER_DIR requires CLUSTER_SECRET")
+	}
+	log.Printf("CLUSTER_ADDR: %q", addr)
+
+	c, err := mindmeld.NewDirCluster(dir, mindmeld.DefaultClusterTTL)
+	if err != nil {
+		return mindmeld.ClusterConfig{}, err
+	}
+	return mindmeld.ClusterConfig{
+		Cluster: c,
+		ID:      os.Getenv("CLUSTER_ID"),
+		Addr:    addr,
+		Secret:  secret,
+	}, nil
+}
+
+func readTokens(path string) (map[string]*mindmeld.Identity, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return mindmeld.ReadTokens(f)
+}
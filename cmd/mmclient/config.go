@@ -0,0 +1,355 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/dhowden/mindmeld"
+	"github.com/dhowden/mindmeld/pb"
+)
+
+// config lists the services to publish and the forwards to open.
+type config struct {
+	Services []serviceConfig `json:"services"`
+	Forwards []forwardConfig `json:"forwards"`
+}
+
+// serviceConfig configures a service (as with -mode listen).
+type serviceConfig struct {
+	Name          string   `json:"name"`
+	Forward       string   `json:"forward"`
+	Allow         []string `json:"allow"`
+	LoadBalancing string   `json:"load_balancing"`
+	ProxyProtocol int      `json:"proxy_protocol"`
+	Multiplex     bool     `json:"multiplex"`
+	E2EKey        string   `json:"e2e_key"` // as with -e2e-key
+}
+
+// forwardConfig configures a forward (as with -mode dial).
+type forwardConfig struct {
+	Service    string `json:"service"`
+	From       string `json:"from"`
+	Multiplex  bool   `json:"multiplex"`
+	E2EKey     string `json:"e2e_key"`     // as with -e2e-key
+	ServiceKey string `json:"service_key"` // as with -e2e-service-key
+}
+
+// readConfig reads a JSON config from path.
+func readConfig(path string) (*config, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	dec := json.NewDecoder(f)
+	dec.DisallowUnknownFields()
+
+	cfg := &config{}
+	if err := dec.Decode(cfg); err != nil {
+		return nil, fmt.Errorf("could not decode config: %w", err)
+	}
+	if err := cfg.validate(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+func (c *config) validate() error {
+	names := make(map[string]bool)
+	for i, s := range c.Services {
+		if s.Name == "" || s.Forward == "" {
+			return fmt.Errorf("services[%d]: name and forward must be set", i)
+		}
+		if names[s.Name] {
+			return fmt.Errorf("services[%d]: duplicate service %q", i, s.Name)
+		}
+		names[s.Name] = true
+		if err := s.validate(); err != nil {
+			return fmt.Errorf("services[%d]: %w", i, err)
+		}
+	}
+
+	froms := make(map[string]bool)
+	for i, f := range c.Forwards {
+		if f.Service == "" || f.From == "" {
+			return fmt.Errorf("forwards[%d]: service and from must be set", i)
+		}
+		if froms[f.From] {
+			return fmt.Errorf("forwards[%d]: duplicate from %q", i, f.From)
+		}
+		froms[f.From] = true
+		if err := f.validate(); err != nil {
+			return fmt.Errorf("forwards[%d]: %w", i, err)
+		}
+	}
+	return nil
+}
+
+// validate checks the service without loading its key, which is only loaded
+// (or generated) by options when the service is started.
+func (s serviceConfig) validate() error {
+	_, err := serviceOptions(s.Allow, s.LoadBalancing, s.ProxyProtocol)
+	return err
+}
+
+func (s serviceConfig) options() ([]mindmeld.Option, error) {
+	opts, err := serviceOptions(s.Allow, s.LoadBalancing, s.ProxyProtocol)
+	if err != nil {
+		return nil, err
+	}
+	if s.Multiplex {
+		opts = append(opts, mindmeld.WithMultiplexing())
+	}
+	if s.E2EKey != "" {
+		key, err := loadKey(s.E2EKey)
+		if err != nil {
+			return nil, fmt.Errorf("could not load e2e_key: %w", err)
+		}
+		opts = append(opts, mindmeld.WithEndToEnd(key))
+	}
+	opts = append(opts, mindmeld.WithReconnect(mindmeld.DefaultBackoff))
+	return opts, nil
+}
+
+// validate checks the forward without loading its key, which is only loaded
+// (or generated) by options when the forward is started.
+func (f forwardConfig) validate() error {
+	if f.ServiceKey == "" {
+		return nil
+	}
+	if _, err := parsePublicKey(f.ServiceKey); err != nil {
+		return err
+	}
+	if f.E2EKey == "" {
+		return fmt.Errorf("service_key requires e2e_key")
+	}
+	return nil
+}
+
+func (f forwardConfig) options() ([]mindmeld.Option, error) {
+	if err := f.validate(); err != nil {
+		return nil, err
+	}
+
+	var opts []mindmeld.Option
+	if f.Multiplex {
+		opts = append(opts, mindmeld.WithMultiplexing())
+	}
+	if f.E2EKey != "" {
+		key, err := loadKey(f.E2EKey)
+		if err != nil {
+			return nil, fmt.Errorf("could not load e2e_key: %w", err)
+		}
+		opts = append(opts, mindmeld.WithEndToEnd(key))
+	}
+	if f.ServiceKey != "" {
+		key, err := parsePublicKey(f.ServiceKey)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, mindmeld.WithServiceKey(key))
+	}
+	return opts, nil
+}
+
+// serviceOptions creates the options for a service.
+func serviceOptions(allow []string, balancing string, proxyProtocol int) ([]mindmeld.Option, error) {
+	var opts []mindmeld.Option
+	if len(allow) > 0 {
+		x, err := mindmeld.ParseAccessList(allow)
+		if err != nil {
+			return nil, fmt.Errorf("invalid access list: %w", err)
+		}
+		opts = append(opts, mindmeld.WithAccessList(x))
+	}
+
+	if balancing != "" {
+		policy, ok := pb.LoadBalancing_value[strings.ToUpper(strings.ReplaceAll(balancing, "-", "_"))]
+		if !ok {
+			return nil, fmt.Errorf("invalid load balancing policy: %q", balancing)
+		}
+		opts = append(opts, mindmeld.WithLoadBalancing(pb.LoadBalancing(policy)))
+	}
+
+	switch proxyProtocol {
+	case 0:
+	case 1, 2:
+		opts = append(opts, mindmeld.WithProxyProtocol(proxyProtocol))
+	default:
+		return nil, fmt.Errorf("invalid PROXY protocol version: %d", proxyProtocol)
+	}
+	return opts, nil
+}
+
+// Time to wait before restarting an entry which has stopped.
+const restartDelay = 5 * time.Second
+
+// supervisor runs the entries of a config, restarting them if they stop.
+// Services deleted by an admin are not restarted until their config changes.
+type supervisor struct {
+	cc *grpc.ClientConn
+
+	mu      sync.Mutex // protects running
+	running map[string]*entry
+}
+
+func newSupervisor(cc *grpc.ClientConn) *supervisor {
+	return &supervisor{
+		cc:      cc,
+		running: make(map[string]*entry),
+	}
+}
+
+// entry is a running service or forward.
+type entry struct {
+	cfg interface{} // serviceConfig or forwardConfig
+
+	stopOnce sync.Once
+	stop     chan struct{}
+}
+
+func (e *entry) close() {
+	e.stopOnce.Do(func() {
+		close(e.stop)
+	})
+}
+
+// apply starts the entries of cfg which aren't already running, and stops
+// those which are no longer in cfg.  Entries which have changed are
+// restarted.  Returns the number of entries started and stopped.
+func (s *supervisor) apply(cfg *config) (started, stopped int) {
+	want := make(map[string]interface{})
+	for _, sc := range cfg.Services {
+		want["service "+sc.Name] = sc
+	}
+	for _, fc := range cfg.Forwards {
+		want["forward "+fc.From] = fc
+	}
+
+	defer s.mu.Unlock()
+	s.mu.Lock()
+
+	for key, e := range s.running {
+		if c, ok := want[key]; ok && reflect.DeepEqual(c, e.cfg) {
+			continue
+		}
+		log.Printf("Stopping %v", key)
+		e.close()
+		delete(s.running, key)
+		stopped++
+	}
+
+	for key, c := range want {
+		if _, ok := s.running[key]; ok {
+			continue
+		}
+		log.Printf("Starting %v", key)
+		e := &entry{
+			cfg:  c,
+			stop: make(chan struct{}),
+		}
+		s.running[key] = e
+		go s.run(key, e)
+		started++
+	}
+	return started, stopped
+}
+
+// run the entry until it is stopped.
+func (s *supervisor) run(key string, e *entry) {
+	for {
+		var err error
+		switch c := e.cfg.(type) {
+		case serviceConfig:
+			err = s.runService(c, e.stop)
+		case forwardConfig:
+			err = s.runForward(c, e.stop)
+		}
+
+		select {
+		case <-e.stop:
+			return
+		default:
+		}
+		if deleted(err) {
+			log.Printf("%v stopped (not restarting until its config changes): %v", key, err)
+			return
+		}
+		log.Printf("%v stopped (restarting in %v): %v", key, restartDelay, err)
+
+		select {
+		case <-e.stop:
+			return
+		case <-time.After(restartDelay):
+		}
+	}
+}
+
+// deleted returns true if err is the router ending a service deleted by an
+// admin (see mindmeld.Server.DeleteService).
+func deleted(err error) bool {
+	var se interface{ GRPCStatus() *status.Status }
+	return errors.As(err, &se) && se.GRPCStatus().Code() == codes.Aborted
+}
+
+func (s *supervisor) runService(c serviceConfig, stop <-chan struct{}) error {
+	opts, err := c.options()
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	sc := mindmeld.NewServiceClient(s.cc, c.Name, c.Forward, opts...)
+	defer sc.Close()
+
+	go func() {
+		select {
+		case <-stop:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	if err := sc.Register(ctx); err != nil {
+		return err
+	}
+	return fmt.Errorf("service %q closed by router", c.Name)
+}
+
+func (s *supervisor) runForward(c forwardConfig, stop <-chan struct{}) error {
+	opts, err := c.options()
+	if err != nil {
+		return err
+	}
+
+	fc := mindmeld.NewForwardClient(s.cc, c.Service, c.From, opts...)
+
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-stop:
+			fc.Close()
+		case <-done:
+		}
+	}()
+
+	err = fc.Forward()
+	fc.Close()
+	return err
+}
@@ -0,0 +1,168 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func writeConfig(t *testing.T, s string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "config.json")
+	if err := ioutil.WriteFile(path, []byte(s), 0600); err != nil {
+		t.Fatalf("could not write config: %v", err)
+	}
+	return path
+}
+
+func TestReadConfig(t *testing.T) {
+	tests := []struct {
+		name   string
+		config string
+		err    string
+	}{
+		{
+			name: "valid",
+			config: `{
+				"services": [{"name": "web", "forward": "localhost:8080", "allow": ["bob", "group:dev"], "load_balancing": "least-connections"}],
+				"forwards": [{"service": "db", "from": "localhost:5432", "multiplex": true}]
+			}`,
+		},
+		{
+			name:   "unknown field",
+			config: `{"services": [{"name": "web", "forward": "localhost:8080", "port": 80}]}`,
+			err:    "unknown field",
+		},
+		{
+			name:   "missing forward",
+			config: `{"services": [{"name": "web"}]}`,
+			err:    "name and forward must be set",
+		},
+		{
+			name:   "duplicate service",
+			config: `{"services": [{"name": "web", "forward": "a:1"}, {"name": "web", "forward": "b:1"}]}`,
+			err:    "duplicate service",
+		},
+		{
+			name:   "invalid load balancing",
+			config: `{"services": [{"name": "web", "forward": "a:1", "load_balancing": "fastest"}]}`,
+			err:    "invalid load balancing",
+		},
+		{
+			name:   "invalid service key",
+			config: `{"forwards": [{"service": "a", "from": "localhost:1", "service_key": "bm9wZQ=="}]}`,
+			err:    "invalid public key",
+		},
+		{
+			name:   "service key without e2e key",
+			config: `{"forwards": [{"service": "a", "from": "localhost:1", "service_key": "AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA="}]}`,
+			err:    "service_key requires e2e_key",
+		},
+		{
+			name:   "duplicate from",
+			config: `{"forwards": [{"service": "a", "from": "localhost:1"}, {"service": "b", "from": "localhost:1"}]}`,
+			err:    "duplicate from",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := readConfig(writeConfig(t, tt.config))
+			if tt.err == "" {
+				if err != nil {
+					t.Errorf("readConfig() = %v, expected nil error", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("readConfig() = %v, expected error containing %q", err, tt.err)
+			}
+		})
+	}
+}
+
+func TestReadConfigE2EKey(t *testing.T) {
+	// Only the entries with e2e_key use end-to-end encryption.
+	key := filepath.Join(t.TempDir(), "key.pem")
+	cfg, err := readConfig(writeConfig(t, fmt.Sprintf(`{
+		"services": [{"name": "web", "forward": "localhost:8080", "e2e_key": %q}],
+		"forwards": [{"service": "db", "from": "localhost:5432"}]
+	}`, key)))
+	if err != nil {
+		t.Fatalf("readConfig() = %v", err)
+	}
+	if cfg.Forwards[0].E2EKey != "" {
+		t.Errorf("forwards[0].E2EKey = %q, expected empty", cfg.Forwards[0].E2EKey)
+	}
+
+	// The key is generated when the service is started, not when the config
+	// is read.
+	if _, err := os.Stat(key); !os.IsNotExist(err) {
+		t.Errorf("e2e_key was generated by readConfig(): %v", err)
+	}
+	if _, err := cfg.Services[0].options(); err != nil {
+		t.Fatalf("options() = %v", err)
+	}
+	if _, err := os.Stat(key); err != nil {
+		t.Errorf("e2e_key was not generated: %v", err)
+	}
+}
+
+func TestSupervisorApply(t *testing.T) {
+	// Entries are never able to connect, and so just keep retrying.
+	cc, err := grpc.Dial("localhost:0", grpc.WithInsecure())
+	if err != nil {
+		t.Fatalf("could not dial: %v", err)
+	}
+	defer cc.Close()
+
+	s := newSupervisor(cc)
+	defer s.apply(&config{})
+
+	apply := func(c *config, wantStarted, wantStopped int) {
+		t.Helper()
+		started, stopped := s.apply(c)
+		if started != wantStarted || stopped != wantStopped {
+			t.Errorf("apply() = %d, %d, expected %d, %d", started, stopped, wantStarted, wantStopped)
+		}
+	}
+
+	apply(&config{
+		Services: []serviceConfig{{Name: "a", Forward: "localhost:1"}, {Name: "b", Forward: "localhost:2"}},
+	}, 2, 0)
+
+	// Unchanged entries are left running, changed entries are restarted.
+	apply(&config{
+		Services: []serviceConfig{{Name: "a", Forward: "localhost:1"}, {Name: "b", Forward: "localhost:3"}},
+	}, 1, 1)
+
+	apply(&config{
+		Services: []serviceConfig{{Name: "a", Forward: "localhost:1"}},
+		Forwards: []forwardConfig{{Service: "b", From: "localhost:0"}},
+	}, 1, 1)
+}
+
+func TestDeleted(t *testing.T) {
+	tests := []struct {
+		err  error
+		want bool
+	}{
+		{fmt.Errorf("could not receive: %w", status.Error(codes.Aborted, "service \"a\" was deleted")), true},
+		{fmt.Errorf("could not receive: %w", status.Error(codes.Unavailable, "server closed")), false},
+		{fmt.Errorf("service %q closed by router", "a"), false},
+	}
+
+	for _, tt := range tests {
+		if got := deleted(tt.err); got != tt.want {
+			t.Errorf("deleted(%v) = %v, expected %v", tt.err, got, tt.want)
+		}
+	}
+}
@@ -7,7 +7,11 @@ import (
 	"fmt"
 	"io"
 	"log"
+	"net"
 	"os"
+	"os/signal"
+	"strings"
+	"syscall"
 	"text/tabwriter"
 	"time"
 
@@ -22,12 +26,28 @@ var (
 	node     = flag.String("node", "", "mindmeld node")
 	insecure = flag.Bool("insecure", false, "connection to gRPC is insecure")
 
-	mode = flag.String("mode", "listen", "mode to operate: listen|dial|list")
+	token    = flag.String("token", os.Getenv("MINDMELD_TOKEN"), "bearer token used to authenticate with the router (default $MINDMELD_TOKEN)")
+	certFile = flag.String("cert", "", "client certificate `file` used to authenticate with the router")
+	keyFile  = flag.String("key", "", "client key `file`")
 
-	serviceName    = flag.String("service-name", "", "service name to use")
-	serviceForward = flag.String("service-forward", "", "will forward incoming service traffic to `host:port`")
+	mode = flag.String("mode", "listen", "mode to operate: listen|dial|socks|list|conns")
 
-	forwardFrom = flag.String("forward-from", "localhost:9999", "bind address for listener")
+	reconnect = flag.Bool("reconnect", true, "re-register services (with backoff) when the connection to the router is lost")
+
+	multiplex = flag.Bool("multiplex", false, "carry all proxy connections over a single stream to the router")
+
+	e2eKey        = flag.String("e2e-key", "", "Ed25519 key `file` (PEM, generated if missing) used to encrypt connections end-to-end between forwarders and services")
+	e2eServiceKey = flag.String("e2e-service-key", "", "base64 public `key` the service must use for end-to-end encryption (default as advertised by the router)")
+
+	serviceName          = flag.String("service-name", "", "service name to use")
+	serviceForward       = flag.String("service-forward", "", "will forward incoming service traffic to `host:port` (udp:host:port for UDP, unix:path for unix sockets)")
+	serviceBalancing     = flag.String("service-balancing", "round-robin", "load balancing policy between instances of the service: round-robin|least-connections|random")
+	serviceProxyProtocol = flag.Int("service-proxy-protocol", 0, "write a PROXY protocol `version` (1 or 2) header on connections to the service target (0 to disable)")
+	serviceAllow         = flag.String("service-allow", "", "comma-separated `list` of identities, group:<name> and CIDRs allowed to forward to the service (default all)")
+
+	configFile = flag.String("config", "", "JSON `file` listing services and forwards to run (reloaded on SIGHUP); overrides -mode")
+
+	forwardFrom = flag.String("forward-from", "localhost:9999", "bind address for listener (udp:host:port for UDP, unix:path for unix sockets), or for the proxy in socks mode")
 )
 
 func main() {
@@ -47,20 +67,92 @@ func main() {
 		}
 
 		tw := newTabWriter()
-		tw.Writef("CREATED\tNAME\n")
+		tw.Writef("CREATED\tNAME\tOWNER\tINSTANCES\n")
 		for _, svc := range resp.GetServices() {
-			tw.Writef("%v\t%v\n", svc.GetCreateTime().AsTime().Local().Format(time.Stamp), svc.GetName())
+			tw.Writef("%v\t%v\t%v\t%v\n", svc.GetCreateTime().AsTime().Local().Format(time.Stamp), svc.GetName(), svc.GetOwner(), svc.GetInstances())
+		}
+		tw.Flush()
+		return
+	}
+
+	if *mode == "conns" {
+		resp, err := pb.NewControlServiceClient(cc).ListConnections(context.Background(), &pb.ListConnectionsRequest{})
+		if err != nil {
+			log.Fatalf("Could not list connections: %v", err)
+		}
+
+		tw := newTabWriter()
+		tw.Writef("STARTED\tID\tSERVICE\tIDENTITY\tADDR\tSTATE\tUP\tDOWN\n")
+		for _, c := range resp.GetConnections() {
+			tw.Writef("%v\t%v\t%v\t%v\t%v\t%v\t%v\t%v\n", c.GetStartTime().AsTime().Local().Format(time.Stamp), c.GetId(), c.GetService(), c.GetPeer().GetIdentity(), c.GetPeer().GetAddr(), c.GetState(), c.GetBytesUp(), c.GetBytesDown())
 		}
 		tw.Flush()
 		return
 	}
 
+	if *configFile != "" {
+		if *e2eKey != "" || *e2eServiceKey != "" {
+			log.Fatalf("-e2e-key and -e2e-service-key can't be used with -config (set e2e_key and service_key for each entry)")
+		}
+		runConfig(cc, *configFile)
+		return
+	}
+
+	var opts []mindmeld.Option
+	if *e2eKey != "" {
+		key, err := loadKey(*e2eKey)
+		if err != nil {
+			log.Fatalf("Could not load key: %v", err)
+		}
+		log.Printf("Using end-to-end encryption (public key: %v)", publicKeyString(key))
+		opts = append(opts, mindmeld.WithEndToEnd(key))
+	}
+	if *multiplex {
+		opts = append(opts, mindmeld.WithMultiplexing())
+	}
+	if *e2eServiceKey != "" {
+		key, err := parsePublicKey(*e2eServiceKey)
+		if err != nil {
+			log.Fatalf("Invalid -e2e-service-key: %v", err)
+		}
+		opts = append(opts, mindmeld.WithServiceKey(key))
+	}
+
+	if *mode == "socks" {
+		l, err := net.Listen("tcp", *forwardFrom)
+		if err != nil {
+			log.Fatalf("Could not listen: %v", err)
+		}
+		defer l.Close()
+
+		log.Printf("Serving SOCKS5 and HTTP CONNECT proxy for <service>.%v on %q", mindmeld.SOCKSDomain, *forwardFrom)
+		d := mindmeld.NewDialer(cc, opts...)
+		if err := mindmeld.NewSOCKSProxy(d).Serve(l); err != nil {
+			log.Fatalf("Could not serve proxy: %v", err)
+		}
+		d.Close()
+		return
+	}
+
 	if *serviceName == "" {
 		log.Fatalf("-service must not be empty")
 	}
 
 	if *mode == "listen" {
-		sc := mindmeld.NewServiceClient(cc, *serviceName, *serviceForward)
+		var allow []string
+		if *serviceAllow != "" {
+			allow = strings.Split(*serviceAllow, ",")
+		}
+		sopts, err := serviceOptions(allow, *serviceBalancing, *serviceProxyProtocol)
+		if err != nil {
+			log.Fatalf("Invalid service flags: %v", err)
+		}
+		opts = append(opts, sopts...)
+		if *reconnect {
+			opts = append(opts, mindmeld.WithReconnect(mindmeld.DefaultBackoff))
+		}
+
+		sc := mindmeld.NewServiceClient(cc, *serviceName, *serviceForward, opts...)
 		if err := sc.Register(context.Background()); err != nil {
 			log.Fatalf("Could not register service: %v", err)
 		}
@@ -70,7 +162,7 @@ func main() {
 
 	if *mode == "dial" {
 		log.Printf("Creating forward from %q to service %q", *forwardFrom, *serviceName)
-		fc := mindmeld.NewForwardClient(cc, *serviceName, *forwardFrom)
+		fc := mindmeld.NewForwardClient(cc, *serviceName, *forwardFrom, opts...)
 		if err := fc.Forward(); err != nil {
 			log.Fatalf("Could not forward: %v", err)
 		}
@@ -79,22 +171,62 @@ func main() {
 	}
 }
 
+// runConfig runs the services and forwards in the config file at path,
+// reloading it on SIGHUP.
+func runConfig(cc *grpc.ClientConn, path string) {
+	cfg, err := readConfig(path)
+	if err != nil {
+		log.Fatalf("Could not read config: %v", err)
+	}
+
+	s := newSupervisor(cc)
+	s.apply(cfg)
+
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	for range hup {
+		cfg, err := readConfig(path)
+		if err != nil {
+			log.Printf("Could not reload config (keeping current config): %v", err)
+			continue
+		}
+		started, stopped := s.apply(cfg)
+		log.Printf("Reloaded config: %d started, %d stopped", started, stopped)
+	}
+}
+
 func dialGRPC(addr string, insecure bool) (*grpc.ClientConn, error) {
 	opts := []grpc.DialOption{grpc.WithBlock()}
 	if *node != "" {
 		opts = append(opts, grpc.WithAuthority(*node))
 	}
+	if *token != "" {
+		opts = append(opts, grpc.WithPerRPCCredentials(mindmeld.BearerToken{
+			Token:         *token,
+			AllowInsecure: insecure,
+		}))
+	}
 	if insecure {
+		if *certFile != "" {
+			return nil, fmt.Errorf("-cert cannot be used with -insecure")
+		}
 		opts = append(opts, grpc.WithInsecure())
 	} else {
 		systemRoots, err := SystemRoots()
 		if err != nil {
 			return nil, fmt.Errorf("could not get system certs: %w", err)
 		}
-		cred := credentials.NewTLS(&tls.Config{
+		cfg := &tls.Config{
 			RootCAs: systemRoots,
-		})
-		opts = append(opts, grpc.WithTransportCredentials(cred))
+		}
+		if *certFile != "" {
+			cert, err := tls.LoadX509KeyPair(*certFile, *keyFile)
+			if err != nil {
+				return nil, fmt.Errorf("could not load client certificate: %w", err)
+			}
+			cfg.Certificates = []tls.Certificate{cert}
+		}
+		opts = append(opts, grpc.WithTransportCredentials(credentials.NewTLS(cfg)))
 	}
 
 	cc, err := grpc.Dial(addr, opts...)
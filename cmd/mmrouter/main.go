@@ -1,11 +1,20 @@
 package main
 
 import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
 	"flag"
+	"fmt"
+	"io/ioutil"
 	"log"
 	"net"
+	"net/http"
+	"os"
+	"strings"
 
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
 
 	"github.com/dhowden/mindmeld"
 	"github.com/dhowden/mindmeld/internal/protoproxy"
@@ -14,6 +23,30 @@ import (
 var (
 	proxyBind = flag.String("proxy-bind", "", "host:port for TCP proxy")
 	proxyDial = flag.String("proxy-dial", "", "dial address for clients to reach TCP proxy")
+
+	authTokens = flag.String("auth-tokens", "", "`file` of \"<token> <identity> [<groups>]\" lines used to authenticate clients")
+	admins     = flag.String("admins", "", "comma-separated `list` of identities and group:<name> allowed to use the admin service")
+
+	tlsCert  = flag.String("tls-cert", "", "TLS certificate `file` (enables TLS)")
+	tlsKey   = flag.String("tls-key", "", "TLS key `file`")
+	clientCA = flag.String("client-ca", "", "CA certificate `file` used to verify client certificates (authenticates clients by certificate common name)")
+
+	registryFile = flag.String("registry", "", "`file` used to keep service definitions across restarts (in memory if empty)")
+	leaseGrace   = flag.Duration("lease-grace", 0, "time to keep a service (and queue its forwards) after its last client disconnects (0 deletes it immediately)")
+	tokenTTL     = flag.Duration("token-ttl", mindmeld.DefaultTokenTTL, "time allowed for proxy connections to claim their tokens")
+
+	httpBind   = flag.String("http-bind", "", "host:port to serve HTTP(S) requests proxied to services (disabled if empty)")
+	httpDomain = flag.String("http-domain", "", "route HTTP requests for <service>.`domain` to service (otherwise routed by /<service>/ path prefix)")
+
+	sniBind   = flag.String("sni-bind", "", "host:port to accept TLS connections routed to services by server name (disabled if empty)")
+	sniDomain = flag.String("sni-domain", "", "route TLS connections for <service>.`domain` to service (if empty the server name is the service)")
+
+	metricsBind = flag.String("metrics-bind", "", "host:port to serve Prometheus metrics on /metrics (disabled if empty)")
+
+	clusterDir    = flag.String("cluster-dir", "", "`directory` shared by the routers of a cluster (disabled if empty)")
+	clusterID     = flag.String("cluster-id", "", "ID of this router in the cluster (random if empty)")
+	clusterAddr   = flag.String("cluster-addr", "", "host:port other routers in the cluster use to reach this router")
+	clusterSecret = flag.String("cluster-secret", "", "`file` containing the secret shared by the routers of the cluster")
 )
 
 func main() {
@@ -25,16 +58,88 @@ func main() {
 		log.Fatalf("Listen: %v", err)
 	}
 
+	var gopts []grpc.ServerOption
+	var sopts []mindmeld.ServerOption
+	sopts = append(sopts, mindmeld.WithLeaseGrace(*leaseGrace), mindmeld.WithTokenTTL(*tokenTTL))
+	if *registryFile != "" {
+		reg, err := mindmeld.OpenFileRegistry(*registryFile)
+		if err != nil {
+			log.Fatalf("Could not open registry: %v", err)
+		}
+		defer reg.Close()
+		sopts = append(sopts, mindmeld.WithRegistry(reg))
+	}
+	if *tokenTTL <= 0 {
+		log.Fatalf("-token-ttl must be positive")
+	}
+	if *clientCA != "" && *tlsCert == "" {
+		log.Fatalf("-client-ca requires -tls-cert")
+	}
+
+	var cfg *tls.Config
+	if *tlsCert != "" {
+		cfg, err = tlsConfig(*tlsCert, *tlsKey, *clientCA)
+		if err != nil {
+			log.Fatalf("Could not configure TLS: %v", err)
+		}
+		gopts = append(gopts, grpc.Creds(credentials.NewTLS(cfg)))
+		if *clientCA != "" {
+			sopts = append(sopts, mindmeld.WithAuthenticator(mindmeld.NewCertificateAuthenticator()))
+		}
+	}
+
+	if *authTokens != "" {
+		if *clientCA != "" {
+			log.Fatalf("-auth-tokens and -client-ca cannot be used together")
+		}
+		tokens, err := readTokens(*authTokens)
+		if err != nil {
+			log.Fatalf("Could not read tokens: %v", err)
+		}
+		sopts = append(sopts, mindmeld.WithAuthenticator(mindmeld.NewTokenAuthenticator(tokens)))
+	}
+
+	if *admins != "" {
+		x, err := mindmeld.ParseAdmins(strings.Split(*admins, ","))
+		if err != nil {
+			log.Fatalf("Invalid -admins: %v", err)
+		}
+		sopts = append(sopts, mindmeld.WithAdmins(x))
+	}
+
+	if *clusterDir != "" {
+		ccfg, err := clusterConfig(cfg)
+		if err != nil {
+			log.Fatalf("Could not configure cluster: %v", err)
+		}
+		sopts = append(sopts, mindmeld.WithCluster(ccfg))
+	}
+
 	pps := protoproxy.NewServer()
 
-	s := mindmeld.NewServer(*proxyDial)
+	s := mindmeld.NewServer(*proxyDial, sopts...)
 	go func() {
 		if err := s.ProxyListen(pps); err != nil {
 			log.Printf("Listen(): %v", err)
 		}
 	}()
 
-	gs := grpc.NewServer()
+	if *httpBind != "" {
+		go serveHTTP(*httpBind, mindmeld.NewHTTPProxy(s, *httpDomain), cfg)
+	}
+
+	if *sniBind != "" {
+		go serveSNI(*sniBind, mindmeld.NewSNIProxy(s, *sniDomain))
+	}
+
+	if *metricsBind != "" {
+		go func() {
+			log.Printf("Listening for metrics requests on %q...", *metricsBind)
+			log.Printf("http.ListenAndServe(): %v", http.ListenAndServe(*metricsBind, s.MetricsHandler()))
+		}()
+	}
+
+	gs := grpc.NewServer(gopts...)
 	mindmeld.RegisterServer(gs, s)
 	protoproxy.RegisterServer(gs, pps)
 
@@ -43,3 +148,112 @@ func main() {
 		log.Printf("gRPC.Serve(): %v", err)
 	}
 }
+
+// serveHTTP serves h on addr, using TLS if cfg is non-nil.
+func serveHTTP(addr string, h http.Handler, cfg *tls.Config) {
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		log.Fatalf("Listen: %v", err)
+	}
+
+	hs := &http.Server{
+		Handler:   h,
+		TLSConfig: cfg,
+	}
+	if cfg != nil {
+		log.Printf("Listening for HTTPS requests on %q...", addr)
+		err = hs.ServeTLS(l, "", "")
+	} else {
+		log.Printf("Listening for HTTP requests on %q...", addr)
+		err = hs.Serve(l)
+	}
+	log.Printf("http.Serve(): %v", err)
+}
+
+// serveSNI serves p on addr.
+func serveSNI(addr string, p *mindmeld.SNIProxy) {
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		log.Fatalf("Listen: %v", err)
+	}
+
+	log.Printf("Listening for TLS connections on %q...", addr)
+	if err := p.Serve(l); err != nil {
+		log.Printf("SNIProxy.Serve(): %v", err)
+	}
+}
+
+func readTokens(path string) (map[string]*mindmeld.Identity, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return mindmeld.ReadTokens(f)
+}
+
+// clusterConfig configures the router as a member of the cluster in
+// -cluster-dir.  If cfg is non-nil (i.e. TLS is enabled) then other routers
+// are dialed using TLS, presenting the router's certificate.
+func clusterConfig(cfg *tls.Config) (mindmeld.ClusterConfig, error) {
+	if *clusterAddr == "" {
+		return mindmeld.ClusterConfig{}, fmt.Errorf("-cluster-dir requires -cluster-addr")
+	}
+	if *clusterSecret == "" {
+		return mindmeld.ClusterConfig{}, fmt.Errorf("-cluster-dir requires -cluster-secret")
+	}
+	b, err := ioutil.ReadFile(*clusterSecret)
+	if err != nil {
+		return mindmeld.ClusterConfig{}, fmt.Errorf("could not read secret: %w", err)
+	}
+	secret := strings.TrimSpace(string(b))
+	if secret == "" {
+		return mindmeld.ClusterConfig{}, fmt.Errorf("secret in %q is empty", *clusterSecret)
+	}
+
+	c, err := mindmeld.NewDirCluster(*clusterDir, mindmeld.DefaultClusterTTL)
+	if err != nil {
+		return mindmeld.ClusterConfig{}, err
+	}
+
+	out := mindmeld.ClusterConfig{
+		Cluster: c,
+		ID:      *clusterID,
+		Addr:    *clusterAddr,
+		Secret:  secret,
+	}
+	if cfg != nil {
+		creds := credentials.NewTLS(&tls.Config{Certificates: cfg.Certificates})
+		out.Dial = func(ctx context.Context, addr string) (*grpc.ClientConn, error) {
+			return grpc.DialContext(ctx, addr, grpc.WithTransportCredentials(creds))
+		}
+	}
+	return out, nil
+}
+
+func tlsConfig(certFile, keyFile, caFile string) (*tls.Config, error) {
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return nil, fmt.Errorf("could not load key pair: %w", err)
+	}
+
+	cfg := &tls.Config{
+		Certificates: []tls.Certificate{cert},
+	}
+	if caFile == "" {
+		return cfg, nil
+	}
+
+	b, err := ioutil.ReadFile(caFile)
+	if err != nil {
+		return nil, fmt.Errorf("could not read client CA: %w", err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(b) {
+		return nil, fmt.Errorf("no certificates found in %q", caFile)
+	}
+	cfg.ClientCAs = pool
+	cfg.ClientAuth = tls.RequireAndVerifyClientCert
+	return cfg, nil
+}
@@ -0,0 +1,98 @@
+package mindmeld
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strings"
+
+	"google.golang.org/grpc"
+
+	"github.com/dhowden/mindmeld/pb"
+)
+
+// NewDialer creates a new Dialer which creates connections to services
+// registered with the router on cc.
+func NewDialer(cc *grpc.ClientConn, opts ...Option) *Dialer {
+	o := newOptions(opts)
+	return &Dialer{
+		cc:   cc,
+		opts: o,
+		pd:   newProxyDialer(cc, o.multiplex),
+	}
+}
+
+// Dialer creates connections to services without binding a local listener.
+// DialContext can be used in http.Transport and with grpc.WithContextDialer.
+type Dialer struct {
+	cc   *grpc.ClientConn
+	opts *options
+	pd   *proxyDialer
+}
+
+// Dial connects to the service.  See DialContext.
+func (d *Dialer) Dial(network, service string) (net.Conn, error) {
+	return d.DialContext(context.Background(), network, service)
+}
+
+// DialContext connects to the service.  The network must be "tcp" or "udp"
+// (or one of their variants), matching the service.  For "udp" each Read and
+// Write on the returned connection is a single datagram.
+//
+// Any port in service is ignored, so that "service:port" addresses (as used by
+// http.Transport) can be passed directly.
+//
+// The context is only used while setting up the connection: once returned,
+// the connection is not affected by it.
+//
+// The returned connection's RemoteAddr is the service, and LocalAddr is the
+// caller as seen by the router (both are *Addr).
+func (d *Dialer) DialContext(ctx context.Context, network, service string) (net.Conn, error) {
+	if !strings.HasPrefix(network, "tcp") && !strings.HasPrefix(network, "udp") {
+		return nil, fmt.Errorf("unsupported network %q", network)
+	}
+
+	if host, _, err := net.SplitHostPort(service); err == nil {
+		service = host
+	}
+
+	resp, err := pb.NewControlServiceClient(d.cc).ForwardToService(ctx, &pb.ForwardToServiceRequest{
+		Name:      service,
+		Protocol:  protocol(network),
+		PublicKey: d.opts.publicKey(),
+	})
+	if err != nil {
+		// Return the gRPC error as-is so that callers can use status.Code.
+		return nil, err
+	}
+	if err := d.opts.checkServiceKey(resp.GetServiceKey()); err != nil {
+		return nil, err
+	}
+
+	pc, err := d.pd.dial(resp.GetToken())
+	if err != nil {
+		return nil, fmt.Errorf("could not create proxy connection: %w", err)
+	}
+	pc.SetAddr(newAddr(service, resp.GetPeer()), &Addr{Service: service})
+
+	var c net.Conn = pc
+	if d.opts.e2eKey != nil {
+		c, err = e2eClient(pc, d.opts.e2eKey, resp.GetServiceKey())
+		if err != nil {
+			pc.Close()
+			return nil, err
+		}
+	}
+
+	if protocol(network) == pb.Protocol_UDP {
+		return &datagramConn{c}, nil
+	}
+	return c, nil
+}
+
+// Close the Dialer.  When using multiplexing this closes the shared stream,
+// and so all connections created by the Dialer.
+func (d *Dialer) Close() error {
+	d.pd.close()
+	return nil
+}
@@ -0,0 +1,104 @@
+package mindmeld_test
+
+import (
+	"context"
+	"io"
+	"io/ioutil"
+	"sync"
+	"testing"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/dhowden/mindmeld"
+)
+
+func TestDialer(t *testing.T) {
+	const msg = "hello world!"
+
+	r := NewTestRouter(t)
+	cc := r.ClientConn(t)
+
+	l, err := mindmeld.Listen(context.Background(), cc, "echo")
+	if err != nil {
+		t.Fatalf("Listen() = %v", err)
+	}
+	defer l.Close()
+
+	go func() {
+		c, err := l.Accept()
+		if err != nil {
+			t.Errorf("Accept() = %v", err)
+			return
+		}
+		defer c.Close()
+		io.WriteString(c, msg)
+	}()
+
+	waitForService(t, cc, "echo")
+
+	c, err := mindmeld.NewDialer(cc).DialContext(context.Background(), "tcp", "echo:80")
+	if err != nil {
+		t.Fatalf("DialContext() = %v", err)
+	}
+	defer c.Close()
+
+	got, err := ioutil.ReadAll(c)
+	if err != nil {
+		t.Fatalf("could not read: %v", err)
+	}
+	if string(got) != msg {
+		t.Errorf("got %q, want %q", got, msg)
+	}
+}
+
+func TestDialerNotFound(t *testing.T) {
+	r := NewTestRouter(t)
+	cc := r.ClientConn(t)
+
+	_, err := mindmeld.NewDialer(cc).DialContext(context.Background(), "tcp", "missing")
+	if status.Code(err) != codes.NotFound {
+		t.Errorf("DialContext() = %v, want code %v", err, codes.NotFound)
+	}
+}
+
+func TestDialerMultiplexing(t *testing.T) {
+	r := NewTestRouter(t)
+	cc := r.ClientConn(t)
+
+	l, err := mindmeld.Listen(context.Background(), cc, "svc", mindmeld.WithMultiplexing())
+	if err != nil {
+		t.Fatalf("Listen() = %v", err)
+	}
+	defer l.Close()
+	go serveName(l, "svc")
+
+	waitForService(t, cc, "svc")
+
+	d := mindmeld.NewDialer(cc, mindmeld.WithMultiplexing())
+	defer d.Close()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			c, err := d.DialContext(context.Background(), "tcp", "svc")
+			if err != nil {
+				t.Errorf("DialContext() = %v", err)
+				return
+			}
+			defer c.Close()
+
+			got, err := ioutil.ReadAll(c)
+			if err != nil {
+				t.Errorf("could not read: %v", err)
+			}
+			if string(got) != "svc" {
+				t.Errorf("got %q, want %q", got, "svc")
+			}
+		}()
+	}
+	wg.Wait()
+}
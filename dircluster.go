@@ -0,0 +1,257 @@
+package mindmeld
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// dirMember is the file written by each router of a DirCluster.
+type dirMember struct {
+	Addr      string            `json:"addr"`
+	Heartbeat time.Time         `json:"heartbeat"`
+	Services  map[string]string `json:"services,omitempty"` // name -> owner
+}
+
+// NewDirCluster creates a Cluster shared through the directory dir, which is
+// created if it doesn't exist.  Routers in separate processes (or on separate
+// hosts, with dir on a shared filesystem such as an NFS mount) use the same
+// dir, and membership expires after ttl (see Cluster), so the clocks of the
+// routers must agree to well within ttl.
+func NewDirCluster(dir string, ttl time.Duration) (*DirCluster, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, fmt.Errorf("could not create cluster directory: %w", err)
+	}
+	return &DirCluster{
+		dir:     dir,
+		ttl:     ttl,
+		members: make(map[string]*dirMember),
+	}, nil
+}
+
+// DirCluster is a Cluster kept in a directory.  Each router writes its
+// address, last heartbeat and services to its own file (<id>.json), and
+// AddService is serialized across routers by a lock file.
+type DirCluster struct {
+	dir string
+	ttl time.Duration
+
+	mu      sync.Mutex            // protects members, and writing their files
+	members map[string]*dirMember // routers in this process
+}
+
+// Join implements Cluster.
+func (c *DirCluster) Join(id, addr string) error {
+	defer c.mu.Unlock()
+	c.mu.Lock()
+
+	m, err := c.member(id)
+	if err != nil {
+		return err
+	}
+	m.Addr = addr
+	m.Heartbeat = time.Now()
+	return c.write(id, m)
+}
+
+// Leave implements Cluster.
+func (c *DirCluster) Leave(id string) error {
+	defer c.mu.Unlock()
+	c.mu.Lock()
+
+	delete(c.members, id)
+	if err := os.Remove(c.path(id)); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("could not remove router %q: %w", id, err)
+	}
+	return nil
+}
+
+// Addr implements Cluster.
+func (c *DirCluster) Addr(id string) (string, error) {
+	m, err := c.read(c.path(id))
+	if err != nil || !c.live(m) {
+		return "", fmt.Errorf("unknown router %q", id)
+	}
+	return m.Addr, nil
+}
+
+// AddService implements Cluster.
+func (c *DirCluster) AddService(name, owner, id string) error {
+	unlock, err := c.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	members, err := c.list()
+	if err != nil {
+		return err
+	}
+	for r, m := range members {
+		if o, ok := m.Services[name]; ok && r != id && o != owner {
+			return ErrServiceOwned
+		}
+	}
+
+	defer c.mu.Unlock()
+	c.mu.Lock()
+
+	m, err := c.member(id)
+	if err != nil {
+		return err
+	}
+	m.Services[name] = owner
+	return c.write(id, m)
+}
+
+// RemoveService implements Cluster.
+func (c *DirCluster) RemoveService(name, id string) error {
+	defer c.mu.Unlock()
+	c.mu.Lock()
+
+	m, ok := c.members[id]
+	if !ok {
+		return nil
+	}
+	delete(m.Services, name)
+	return c.write(id, m)
+}
+
+// Routers implements Cluster.
+func (c *DirCluster) Routers(name string) ([]string, error) {
+	members, err := c.list()
+	if err != nil {
+		return nil, err
+	}
+
+	var out []string
+	for id, m := range members {
+		if _, ok := m.Services[name]; ok {
+			out = append(out, id)
+		}
+	}
+	return out, nil
+}
+
+// member returns the router id in this process, adding it if necessary.
+// Must be called with mu held.
+func (c *DirCluster) member(id string) (*dirMember, error) {
+	if id == "" || strings.ContainsAny(id, `./\`) {
+		return nil, fmt.Errorf("invalid router id %q", id)
+	}
+	m, ok := c.members[id]
+	if !ok {
+		m = &dirMember{
+			Services: make(map[string]string),
+		}
+		c.members[id] = m
+	}
+	return m, nil
+}
+
+func (c *DirCluster) path(id string) string {
+	return filepath.Join(c.dir, id+".json")
+}
+
+// live returns true if m has renewed its membership within the TTL.
+func (c *DirCluster) live(m *dirMember) bool {
+	return time.Since(m.Heartbeat) < c.ttl
+}
+
+// write atomically replaces the file of the router id with m.  Must be called
+// with mu held.
+func (c *DirCluster) write(id string, m *dirMember) error {
+	b, err := json.Marshal(m)
+	if err != nil {
+		return fmt.Errorf("could not encode router %q: %w", id, err)
+	}
+
+	f, err := os.CreateTemp(c.dir, id+".json.tmp")
+	if err != nil {
+		return fmt.Errorf("could not write router %q: %w", id, err)
+	}
+	defer os.Remove(f.Name())
+
+	if _, err := f.Write(b); err != nil {
+		f.Close()
+		return fmt.Errorf("could not write router %q: %w", id, err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("could not write router %q: %w", id, err)
+	}
+	if err := os.Rename(f.Name(), c.path(id)); err != nil {
+		return fmt.Errorf("could not replace router %q: %w", id, err)
+	}
+	return nil
+}
+
+func (c *DirCluster) read(path string) (*dirMember, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	m := &dirMember{}
+	if err := json.Unmarshal(b, m); err != nil {
+		return nil, fmt.Errorf("could not decode %q: %w", path, err)
+	}
+	return m, nil
+}
+
+// list returns the live routers in the cluster.
+func (c *DirCluster) list() (map[string]*dirMember, error) {
+	paths, err := filepath.Glob(filepath.Join(c.dir, "*.json"))
+	if err != nil {
+		return nil, fmt.Errorf("could not list routers: %w", err)
+	}
+
+	out := make(map[string]*dirMember, len(paths))
+	for _, path := range paths {
+		m, err := c.read(path)
+		if os.IsNotExist(err) {
+			continue // the router left
+		}
+		if err != nil {
+			return nil, fmt.Errorf("could not read router: %w", err)
+		}
+		if c.live(m) {
+			out[strings.TrimSuffix(filepath.Base(path), ".json")] = m
+		}
+	}
+	return out, nil
+}
+
+// errClusterLocked is returned when the cluster lock can't be acquired within
+// the TTL.
+var errClusterLocked = errors.New("timed out waiting for cluster lock")
+
+// lock acquires the lock file of the cluster, returning a func which releases
+// it.  Lock files older than the TTL are assumed to have been left behind by
+// a router which crashed, and are removed.
+func (c *DirCluster) lock() (func(), error) {
+	path := filepath.Join(c.dir, "cluster.lock")
+	deadline := time.Now().Add(c.ttl)
+	for {
+		f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+		if err == nil {
+			f.Close()
+			return func() { os.Remove(path) }, nil
+		}
+		if !os.IsExist(err) {
+			return nil, fmt.Errorf("could not lock cluster: %w", err)
+		}
+
+		if fi, err := os.Stat(path); err == nil && time.Since(fi.ModTime()) > c.ttl {
+			os.Remove(path)
+			continue
+		}
+		if time.Now().After(deadline) {
+			return nil, errClusterLocked
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
@@ -0,0 +1,150 @@
+package mindmeld
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"math/big"
+	"net"
+	"time"
+)
+
+// e2eHandshakeTimeout is the time allowed for the end-to-end TLS handshake.
+const e2eHandshakeTimeout = 10 * time.Second
+
+// WithEndToEnd encrypts connections between forwarders and the service
+// end-to-end, so that the router only passes ciphertext.  The public half of
+// key is advertised to the router: for services when they are created, and
+// for forwarders with each forward.  Each connection is then wrapped in TLS
+// (1.3) between the forwarder and the service, where each side authenticates
+// the other by the public key advertised by the router.
+//
+// Services and forwards must either both use end-to-end encryption or both
+// not use it.  All instances of a service must use the same key.
+//
+// Applies to all clients.
+func WithEndToEnd(key ed25519.PrivateKey) Option {
+	return func(o *options) {
+		o.e2eKey = key
+	}
+}
+
+// WithServiceKey pins the public key of the service, which is otherwise
+// trusted as advertised by the router.  Forwards fail if the router
+// advertises a different key.  Requires WithEndToEnd.
+//
+// Applies to ForwardClient and Dialer.
+func WithServiceKey(key ed25519.PublicKey) Option {
+	return func(o *options) {
+		o.serviceKey = key
+	}
+}
+
+// publicKey returns the public key used for end-to-end encryption (nil if not
+// used).
+func (o *options) publicKey() []byte {
+	if o.e2eKey == nil {
+		return nil
+	}
+	return o.e2eKey.Public().(ed25519.PublicKey)
+}
+
+// checkServiceKey checks the service key advertised by the router against the
+// pinned key (if any).
+func (o *options) checkServiceKey(key []byte) error {
+	if o.serviceKey == nil {
+		return nil
+	}
+	if o.e2eKey == nil {
+		return errors.New("service key pinned without end-to-end encryption")
+	}
+	if !bytes.Equal(o.serviceKey, key) {
+		return errors.New("service key does not match pinned key")
+	}
+	return nil
+}
+
+// e2eClient wraps c (a connection to a service) in TLS authenticated by key,
+// and completes the handshake.  The service must present serviceKey.
+func e2eClient(c net.Conn, key ed25519.PrivateKey, serviceKey []byte) (net.Conn, error) {
+	cfg, err := e2eConfig(key, serviceKey)
+	if err != nil {
+		return nil, err
+	}
+	return e2eHandshake(c, tls.Client(c, cfg))
+}
+
+// e2eServer wraps c (a connection from a forwarder) in TLS authenticated by
+// key, and completes the handshake.  The forwarder must present peerKey.
+func e2eServer(c net.Conn, key ed25519.PrivateKey, peerKey []byte) (net.Conn, error) {
+	cfg, err := e2eConfig(key, peerKey)
+	if err != nil {
+		return nil, err
+	}
+	cfg.ClientAuth = tls.RequireAnyClientCert
+	return e2eHandshake(c, tls.Server(c, cfg))
+}
+
+func e2eHandshake(c net.Conn, tc *tls.Conn) (net.Conn, error) {
+	c.SetDeadline(time.Now().Add(e2eHandshakeTimeout))
+	if err := tc.Handshake(); err != nil {
+		return nil, fmt.Errorf("end-to-end handshake failed: %w", err)
+	}
+	c.SetDeadline(time.Time{})
+	return tc, nil
+}
+
+// e2eConfig returns a TLS config which presents a certificate for key, and
+// only accepts a peer which presents peerKey.  Certificates are self-signed:
+// the keys are what is authenticated.
+func e2eConfig(key ed25519.PrivateKey, peerKey []byte) (*tls.Config, error) {
+	if len(peerKey) != ed25519.PublicKeySize {
+		return nil, errors.New("end-to-end encryption not used by peer")
+	}
+
+	cert, err := e2eCertificate(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		MinVersion:   tls.VersionTLS13,
+		// Verification is done by VerifyPeerCertificate.
+		InsecureSkipVerify: true,
+		VerifyPeerCertificate: func(rawCerts [][]byte, _ [][]*x509.Certificate) error {
+			if len(rawCerts) == 0 {
+				return errors.New("no peer certificate")
+			}
+			cert, err := x509.ParseCertificate(rawCerts[0])
+			if err != nil {
+				return fmt.Errorf("could not parse peer certificate: %w", err)
+			}
+			pub, ok := cert.PublicKey.(ed25519.PublicKey)
+			if !ok || !bytes.Equal(pub, peerKey) {
+				return errors.New("peer key does not match key advertised by router")
+			}
+			return nil
+		},
+	}, nil
+}
+
+// e2eCertificate creates a self-signed certificate for key.
+func e2eCertificate(key ed25519.PrivateKey) (tls.Certificate, error) {
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(24 * time.Hour),
+	}
+	der, err := x509.CreateCertificate(nil, tmpl, tmpl, key.Public(), key)
+	if err != nil {
+		return tls.Certificate{}, fmt.Errorf("could not create certificate: %w", err)
+	}
+	return tls.Certificate{
+		Certificate: [][]byte{der},
+		PrivateKey:  key,
+	}, nil
+}
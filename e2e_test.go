@@ -0,0 +1,144 @@
+package mindmeld_test
+
+import (
+	"context"
+	"crypto/ed25519"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"strings"
+	"testing"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/dhowden/mindmeld"
+)
+
+func generateKey(t *testing.T) ed25519.PrivateKey {
+	t.Helper()
+
+	_, key, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatalf("GenerateKey() = %v", err)
+	}
+	return key
+}
+
+func TestEndToEnd(t *testing.T) {
+	const msg = "hello world!"
+
+	r := NewTestRouter(t)
+	cc := r.ClientConn(t)
+
+	l, err := net.Listen("tcp", "localhost:0")
+	if err != nil {
+		t.Fatalf("could not listen: %v", err)
+	}
+	defer l.Close()
+	go countServer(l)
+
+	serviceKey := generateKey(t)
+	sc := mindmeld.NewServiceClient(cc, "count", l.Addr().String(), mindmeld.WithEndToEnd(serviceKey))
+	defer sc.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go sc.Register(ctx)
+
+	waitForService(t, cc, "count")
+
+	d := mindmeld.NewDialer(cc,
+		mindmeld.WithEndToEnd(generateKey(t)),
+		mindmeld.WithServiceKey(serviceKey.Public().(ed25519.PublicKey)),
+	)
+	c, err := d.DialContext(context.Background(), "tcp", "count")
+	if err != nil {
+		t.Fatalf("DialContext() = %v", err)
+	}
+	defer c.Close()
+
+	if _, err := io.WriteString(c, msg); err != nil {
+		t.Fatalf("write failed: %v", err)
+	}
+	c.(interface{ CloseWrite() error }).CloseWrite()
+
+	got, err := ioutil.ReadAll(c)
+	if err != nil {
+		t.Fatalf("read failed: %v", err)
+	}
+	if want := fmt.Sprintf("%d", len(msg)); string(got) != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestEndToEndMismatch(t *testing.T) {
+	r := NewTestRouter(t)
+	cc := r.ClientConn(t)
+
+	l, err := mindmeld.Listen(context.Background(), cc, "e2e", mindmeld.WithEndToEnd(generateKey(t)))
+	if err != nil {
+		t.Fatalf("Listen() = %v", err)
+	}
+	defer l.Close()
+	go serveName(l, "e2e")
+
+	plain, err := mindmeld.Listen(context.Background(), cc, "plain")
+	if err != nil {
+		t.Fatalf("Listen() = %v", err)
+	}
+	defer plain.Close()
+	go serveName(plain, "plain")
+
+	waitForService(t, cc, "e2e")
+	waitForService(t, cc, "plain")
+
+	if got := dialRead(t, cc, "plain"); got != "plain" {
+		t.Errorf("dialRead() = %q, want %q", got, "plain")
+	}
+
+	key := generateKey(t)
+	d := mindmeld.NewDialer(cc, mindmeld.WithEndToEnd(key))
+	c, err := d.DialContext(context.Background(), "tcp", "e2e")
+	if err != nil {
+		t.Fatalf("DialContext() = %v", err)
+	}
+	got, err := ioutil.ReadAll(c)
+	c.Close()
+	if err != nil || string(got) != "e2e" {
+		t.Errorf("ReadAll() = %q, %v, want %q", got, err, "e2e")
+	}
+
+	tests := []struct {
+		name    string
+		service string
+		opts    []mindmeld.Option
+		code    codes.Code
+	}{
+		{"no key", "e2e", nil, codes.FailedPrecondition},
+		{"plain service", "plain", []mindmeld.Option{mindmeld.WithEndToEnd(key)}, codes.FailedPrecondition},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := mindmeld.NewDialer(cc, tt.opts...).DialContext(context.Background(), "tcp", tt.service)
+			if status.Code(err) != tt.code {
+				t.Errorf("DialContext() = %v, want code %v", err, tt.code)
+			}
+		})
+	}
+
+	// The router must advertise the pinned key.
+	wrongKey := key.Public().(ed25519.PublicKey)
+	pinned := mindmeld.NewDialer(cc, mindmeld.WithEndToEnd(key), mindmeld.WithServiceKey(wrongKey))
+	if _, err := pinned.DialContext(context.Background(), "tcp", "e2e"); err == nil || !strings.Contains(err.Error(), "does not match pinned key") {
+		t.Errorf("DialContext() = %v, expected error for mismatched pinned key", err)
+	}
+
+	// Keys can only be pinned when using end-to-end encryption.
+	unencrypted := mindmeld.NewDialer(cc, mindmeld.WithServiceKey(wrongKey))
+	if _, err := unencrypted.DialContext(context.Background(), "tcp", "plain"); err == nil || !strings.Contains(err.Error(), "without end-to-end encryption") {
+		t.Errorf("DialContext() = %v, expected error for key pinned without end-to-end encryption", err)
+	}
+}
@@ -7,5 +7,5 @@ require (
 	golang.org/x/net v0.0.0-20190311183353-d8887717615a // indirect
 	google.golang.org/grpc v1.36.1
 	google.golang.org/grpc/cmd/protoc-gen-go-grpc v1.1.0 // indirect
-	google.golang.org/protobuf v1.26.0 // indirect
+	google.golang.org/protobuf v1.26.0
 )
@@ -0,0 +1,178 @@
+package mindmeld_test
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/test/bufconn"
+
+	"github.com/dhowden/mindmeld"
+	"github.com/dhowden/mindmeld/internal/protoproxy"
+
+	"github.com/dhowden/mindmeld/pb"
+)
+
+// TestRouter runs a router (control and proxy services) using in-memory
+// networking.
+type TestRouter struct {
+	l *bufconn.Listener
+
+	*mindmeld.Server
+	gs *grpc.Server
+}
+
+// NewTestRouter creates and starts a TestRouter, which is shutdown when
+// the test completes.
+func NewTestRouter(t *testing.T, opts ...mindmeld.ServerOption) *TestRouter {
+	s := mindmeld.NewServer("", opts...)
+	pps := protoproxy.NewServer()
+	go s.ProxyListen(pps)
+
+	gs := grpc.NewServer()
+	mindmeld.RegisterServer(gs, s)
+	protoproxy.RegisterServer(gs, pps)
+
+	l := bufconn.Listen(1024 * 1024)
+	go gs.Serve(l)
+
+	t.Cleanup(func() {
+		s.Close()
+		gs.Stop()
+	})
+
+	return &TestRouter{
+		l:      l,
+		Server: s,
+		gs:     gs,
+	}
+}
+
+// ClientConn creates a new connection to the router.  Overrides the dialer
+// and sets grpc.WithInsecure.  Calls t.Fatal if the dial fails.
+func (r *TestRouter) ClientConn(t *testing.T, opts ...grpc.DialOption) *grpc.ClientConn {
+	opts = append(opts,
+		grpc.WithContextDialer(func(_ context.Context, _ string) (net.Conn, error) {
+			return r.l.Dial()
+		}),
+		grpc.WithInsecure(),
+	)
+	cc, err := grpc.Dial("bufconn", opts...)
+	if err != nil {
+		t.Fatalf("unexpected error from grpc.Dial: %v", err)
+	}
+	t.Cleanup(func() { cc.Close() })
+	return cc
+}
+
+// newAuthRouter creates and starts a TestRouter (see NewTestRouter) which
+// authenticates callers by the tokens in identities.
+func newAuthRouter(t *testing.T, identities map[string]*mindmeld.Identity, opts ...mindmeld.ServerOption) *TestRouter {
+	opts = append(opts, mindmeld.WithAuthenticator(mindmeld.NewTokenAuthenticator(identities)))
+	return NewTestRouter(t, opts...)
+}
+
+// tokenConn creates a new connection to the router which authenticates with
+// token.
+func tokenConn(t *testing.T, r *TestRouter, token string) *grpc.ClientConn {
+	return r.ClientConn(t, grpc.WithPerRPCCredentials(mindmeld.BearerToken{Token: token, AllowInsecure: true}))
+}
+
+// serveName accepts connections from l, and writes name to each.  The write
+// side of the connection is then closed, and the connection is held open
+// until the caller closes it.
+func serveName(l *mindmeld.Listener, name string) {
+	for {
+		c, err := l.Accept()
+		if err != nil {
+			return
+		}
+		go func() {
+			defer c.Close()
+			io.WriteString(c, name)
+			if cw, ok := c.(interface{ CloseWrite() error }); ok {
+				cw.CloseWrite()
+				io.Copy(ioutil.Discard, c)
+			}
+		}()
+	}
+}
+
+// dialName dials the service (served by serveName), and returns the open
+// connection and everything read from it.
+func dialName(t *testing.T, cc *grpc.ClientConn, service string) (net.Conn, string) {
+	t.Helper()
+
+	c, err := mindmeld.NewDialer(cc).DialContext(context.Background(), "tcp", service)
+	if err != nil {
+		t.Fatalf("DialContext() = %v", err)
+	}
+	b, err := ioutil.ReadAll(c)
+	if err != nil {
+		c.Close()
+		t.Fatalf("could not read: %v", err)
+	}
+	return c, string(b)
+}
+
+// dialRead dials the service and returns everything read from the connection.
+func dialRead(t *testing.T, cc *grpc.ClientConn, service string) string {
+	t.Helper()
+
+	c, name := dialName(t, cc, service)
+	c.Close()
+	return name
+}
+
+// poll calls f until it returns true, failing the test if f returns an error
+// or if it doesn't return true within a second.  what describes the wait.
+func poll(t *testing.T, what string, f func() (bool, error)) {
+	t.Helper()
+
+	deadline := time.Now().Add(time.Second)
+	for {
+		ok, err := f()
+		if err != nil {
+			t.Fatalf("error waiting for %v: %v", what, err)
+		}
+		if ok {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %v", what)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+// waitForServices polls the router until ok returns true for its services.
+func waitForServices(t *testing.T, cc *grpc.ClientConn, what string, ok func([]*pb.Service) bool) {
+	t.Helper()
+
+	poll(t, what, func() (bool, error) {
+		resp, err := pb.NewControlServiceClient(cc).ListServices(context.Background(), &pb.ListServicesRequest{})
+		if err != nil {
+			return false, err
+		}
+		return ok(resp.GetServices()), nil
+	})
+}
+
+// waitForService polls the router until the service name is registered.
+func waitForService(t *testing.T, cc *grpc.ClientConn, name string) {
+	t.Helper()
+
+	waitForServices(t, cc, fmt.Sprintf("%q", name), func(svcs []*pb.Service) bool {
+		for _, svc := range svcs {
+			if svc.GetName() == name {
+				return true
+			}
+		}
+		return false
+	})
+}
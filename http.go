@@ -0,0 +1,174 @@
+package mindmeld
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net"
+	"net/http"
+	"net/http/httputil"
+	"strings"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+var _ http.Handler = (*HTTPProxy)(nil)
+
+// NewHTTPProxy creates an HTTPProxy for services registered with s.  Requests
+// for hosts "<service>.<domain>" are routed to service.  If domain is empty
+// then only path routing is used.
+func NewHTTPProxy(s *Server, domain string) *HTTPProxy {
+	p := &HTTPProxy{
+		s:      s,
+		domain: domain,
+	}
+	p.rp = &httputil.ReverseProxy{
+		Director: func(r *http.Request) {
+			r.URL.Scheme = "http"
+		},
+		Transport: &http.Transport{
+			DialContext: p.dial,
+			// Connections are authorized for the caller of the request
+			// which created them, so must not be reused.
+			DisableKeepAlives: true,
+		},
+		ErrorHandler: p.handleError,
+	}
+	return p
+}
+
+// HTTPProxy is an http.Handler which proxies requests to services registered
+// with a Server, so that they can be reached (i.e. from a browser) without
+// running a client.
+//
+// Requests are routed by Host ("<service>.<domain>"), falling back to the
+// first element of the path: "/<service>/path" is passed to service as "/path"
+// (with X-Forwarded-Prefix set to "/<service>").
+//
+// When the Server has an Authenticator, callers are authenticated in the same
+// way as gRPC requests: using bearer tokens passed as "Authorization: Bearer
+// <token>" (or as the password of basic auth, so that browsers can prompt for
+// it), or TLS client certificates.  The Authorization header is not passed
+// on to the service.
+type HTTPProxy struct {
+	s      *Server
+	domain string
+	rp     *httputil.ReverseProxy
+}
+
+// ServeHTTP implements http.Handler.
+func (p *HTTPProxy) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	name, path, prefix := p.route(r)
+	if name == "" {
+		http.NotFound(w, r)
+		return
+	}
+
+	out := r.Clone(authContext(r))
+	out.URL.Host = name
+	out.URL.Path = path
+	out.URL.RawPath = ""
+	out.Header.Del("Authorization")
+	if prefix != "" {
+		out.Header.Set("X-Forwarded-Prefix", prefix)
+	}
+	p.rp.ServeHTTP(w, out)
+}
+
+// route returns the service and path for r, and the prefix removed from the
+// path (if any).
+func (p *HTTPProxy) route(r *http.Request) (service, path, prefix string) {
+	host := r.Host
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	if p.domain != "" {
+		if name := serviceFromHost(host, p.domain); name != "" {
+			return name, r.URL.Path, ""
+		}
+	}
+
+	parts := strings.SplitN(strings.TrimPrefix(r.URL.Path, "/"), "/", 2)
+	path = "/"
+	if len(parts) == 2 {
+		path += parts[1]
+	}
+	return parts[0], path, "/" + parts[0]
+}
+
+// dial creates a connection to the service for a request.  The service name
+// is the host of addr, and the caller is identified by ctx (see authContext).
+func (p *HTTPProxy) dial(ctx context.Context, _, addr string) (net.Conn, error) {
+	name, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		name = addr
+	}
+	return p.s.DialService(ctx, name)
+}
+
+func (p *HTTPProxy) handleError(w http.ResponseWriter, r *http.Request, err error) {
+	code := http.StatusBadGateway
+	var se interface{ GRPCStatus() *status.Status }
+	if errors.As(err, &se) {
+		switch se.GRPCStatus().Code() {
+		case codes.Unauthenticated:
+			w.Header().Set("WWW-Authenticate", `Basic realm="mindmeld"`)
+			code = http.StatusUnauthorized
+		case codes.PermissionDenied:
+			code = http.StatusForbidden
+		case codes.NotFound:
+			code = http.StatusNotFound
+		}
+		err = errors.New(se.GRPCStatus().Message())
+	}
+
+	log.Printf("Could not proxy HTTP request for %q: %v", r.URL.Host, err)
+	http.Error(w, err.Error(), code)
+}
+
+// authContext returns the context of r, carrying its credentials and address
+// in the form used by gRPC requests (and so Authenticators).
+func authContext(r *http.Request) context.Context {
+	md := metadata.MD{}
+	if token := bearerToken(r); token != "" {
+		md.Set(authorizationKey, "Bearer "+token)
+	}
+	ctx := metadata.NewIncomingContext(r.Context(), md)
+
+	p := &peer.Peer{}
+	if addr, err := net.ResolveTCPAddr("tcp", r.RemoteAddr); err == nil {
+		p.Addr = addr
+	}
+	if r.TLS != nil {
+		p.AuthInfo = credentials.TLSInfo{State: *r.TLS}
+	}
+	return peer.NewContext(ctx, p)
+}
+
+// bearerToken returns the bearer token from the Authorization header of r,
+// which is either "Bearer <token>" or basic auth with the token as password.
+func bearerToken(r *http.Request) string {
+	if _, password, ok := r.BasicAuth(); ok {
+		return password
+	}
+	if h := r.Header.Get("Authorization"); strings.HasPrefix(h, "Bearer ") {
+		return strings.TrimPrefix(h, "Bearer ")
+	}
+	return ""
+}
+
+// serviceFromHost returns the service for host "<service>.<domain>", or ""
+// if host is not in domain.  If domain is empty then host is the service.
+func serviceFromHost(host, domain string) string {
+	if domain == "" {
+		return host
+	}
+	if !strings.HasSuffix(host, "."+domain) {
+		return ""
+	}
+	return strings.TrimSuffix(host, "."+domain)
+}
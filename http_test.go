@@ -0,0 +1,187 @@
+package mindmeld_test
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/dhowden/mindmeld"
+	"github.com/dhowden/mindmeld/pb"
+)
+
+// serveHTTP registers service name, serving h.
+func serveHTTP(t *testing.T, r *TestRouter, name string, h http.Handler, opts ...mindmeld.Option) {
+	t.Helper()
+
+	cc := r.ClientConn(t)
+	l, err := mindmeld.Listen(context.Background(), cc, name, opts...)
+	if err != nil {
+		t.Fatalf("Listen() = %v", err)
+	}
+	t.Cleanup(func() { l.Close() })
+	go http.Serve(l, h)
+
+	waitForService(t, cc, name)
+}
+
+// describe writes the service name, request path and prefix.
+func describe(name string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "%s %s %s %s", name, r.URL.Path, r.Header.Get("X-Forwarded-Prefix"), r.Header.Get("Authorization"))
+	})
+}
+
+func TestHTTPProxy(t *testing.T) {
+	r := NewTestRouter(t)
+	serveHTTP(t, r, "app", describe("app"))
+
+	hs := httptest.NewServer(mindmeld.NewHTTPProxy(r.Server, "dev.example.com"))
+	defer hs.Close()
+
+	tests := []struct {
+		name string
+		host string
+		path string
+		code int
+		body string
+	}{
+		{
+			name: "host",
+			host: "app.dev.example.com",
+			path: "/x/y",
+			code: http.StatusOK,
+			body: "app /x/y  ",
+		},
+		{
+			name: "path",
+			path: "/app/x/y",
+			code: http.StatusOK,
+			body: "app /x/y /app ",
+		},
+		{
+			name: "unknown host",
+			host: "missing.dev.example.com",
+			code: http.StatusNotFound,
+		},
+		{
+			name: "unknown path",
+			path: "/missing/",
+			code: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest("GET", hs.URL+tt.path, nil)
+			if err != nil {
+				t.Fatalf("could not create request: %v", err)
+			}
+			req.Host = tt.host
+
+			resp, err := hs.Client().Do(req)
+			if err != nil {
+				t.Fatalf("request failed: %v", err)
+			}
+			defer resp.Body.Close()
+
+			if resp.StatusCode != tt.code {
+				t.Errorf("status code = %d, expected %d", resp.StatusCode, tt.code)
+			}
+			if tt.body == "" {
+				return
+			}
+			b, err := ioutil.ReadAll(resp.Body)
+			if err != nil {
+				t.Fatalf("could not read body: %v", err)
+			}
+			if got := string(b); got != tt.body {
+				t.Errorf("body = %q, expected %q", got, tt.body)
+			}
+		})
+	}
+}
+
+func TestHTTPProxyAuth(t *testing.T) {
+	r := newAuthRouter(t, map[string]*mindmeld.Identity{
+		"alice-secret": {Name: "alice"},
+		"bob-secret":   {Name: "bob"},
+	})
+
+	cc := tokenConn(t, r, "alice-secret")
+	l, err := mindmeld.Listen(context.Background(), cc, "app", mindmeld.WithAccessList(&pb.AccessList{
+		Identities: []string{"carol"},
+	}))
+	if err != nil {
+		t.Fatalf("Listen() = %v", err)
+	}
+	defer l.Close()
+	go http.Serve(l, describe("app"))
+	waitForService(t, cc, "app")
+
+	hs := httptest.NewServer(mindmeld.NewHTTPProxy(r.Server, ""))
+	defer hs.Close()
+
+	tests := []struct {
+		name  string
+		setup func(*http.Request)
+		code  int
+	}{
+		{
+			name:  "bearer",
+			setup: func(r *http.Request) { r.Header.Set("Authorization", "Bearer alice-secret") },
+			code:  http.StatusOK,
+		},
+		{
+			name:  "basic",
+			setup: func(r *http.Request) { r.SetBasicAuth("", "alice-secret") },
+			code:  http.StatusOK,
+		},
+		{
+			name:  "forbidden",
+			setup: func(r *http.Request) { r.Header.Set("Authorization", "Bearer bob-secret") },
+			code:  http.StatusNotFound,
+		},
+		{
+			name:  "missing",
+			setup: func(r *http.Request) {},
+			code:  http.StatusUnauthorized,
+		},
+		{
+			name:  "invalid",
+			setup: func(r *http.Request) { r.Header.Set("Authorization", "Bearer wrong") },
+			code:  http.StatusUnauthorized,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest("GET", hs.URL+"/app/", nil)
+			if err != nil {
+				t.Fatalf("could not create request: %v", err)
+			}
+			tt.setup(req)
+
+			resp, err := hs.Client().Do(req)
+			if err != nil {
+				t.Fatalf("request failed: %v", err)
+			}
+			defer resp.Body.Close()
+
+			if resp.StatusCode != tt.code {
+				t.Errorf("status code = %d, expected %d", resp.StatusCode, tt.code)
+			}
+			if tt.code != http.StatusOK {
+				return
+			}
+
+			// The router's credentials are not passed to the service.
+			b, _ := ioutil.ReadAll(resp.Body)
+			if got, want := string(b), "app / /app "; got != want {
+				t.Errorf("body = %q, expected %q", got, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,52 @@
+package internal
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+)
+
+// MaxDatagramSize is the largest datagram which can be framed.
+const MaxDatagramSize = 65535
+
+// WriteDatagram writes b to w as a frame prefixed with its length (uint16,
+// little endian).  Frames are delimited by the prefix rather than by payload
+// boundaries: the frame is written with a single call to w.Write, but a
+// proxying connection may still split it across payloads.
+func WriteDatagram(w io.Writer, b []byte) error {
+	if len(b) > MaxDatagramSize {
+		return fmt.Errorf("datagram too large (%d bytes)", len(b))
+	}
+
+	buf := make([]byte, 2+len(b))
+	binary.LittleEndian.PutUint16(buf, uint16(len(b)))
+	copy(buf[2:], b)
+
+	if n, err := w.Write(buf); err != nil {
+		return fmt.Errorf("could not write datagram (failed after %d bytes): %w", n, err)
+	}
+	return nil
+}
+
+// ReadDatagram reads a frame written by WriteDatagram into b, returning the
+// size of the datagram.  If b is too small to hold the datagram then the
+// excess is discarded (as with UDP).
+func ReadDatagram(r io.Reader, b []byte) (int, error) {
+	var sizeBuf [2]byte
+	if _, err := io.ReadFull(r, sizeBuf[:]); err != nil {
+		return 0, err
+	}
+	size := int(binary.LittleEndian.Uint16(sizeBuf[:]))
+
+	n := size
+	if n > len(b) {
+		n = len(b)
+	}
+	if _, err := io.ReadFull(r, b[:n]); err != nil {
+		return 0, fmt.Errorf("could not read datagram (%d bytes): %w", size, err)
+	}
+	if _, err := io.CopyN(io.Discard, r, int64(size-n)); err != nil {
+		return 0, fmt.Errorf("could not read datagram (%d bytes): %w", size, err)
+	}
+	return n, nil
+}
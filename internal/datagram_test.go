@@ -0,0 +1,33 @@
+package internal_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/dhowden/mindmeld/internal"
+)
+
+func TestDatagram(t *testing.T) {
+	var buf bytes.Buffer
+	for _, msg := range []string{"hello", "", "world!"} {
+		if err := internal.WriteDatagram(&buf, []byte(msg)); err != nil {
+			t.Fatalf("WriteDatagram() = %v", err)
+		}
+	}
+
+	// A short buffer truncates the datagram, discarding the rest.
+	b := make([]byte, 4)
+	for _, want := range []string{"hell", "", "worl"} {
+		n, err := internal.ReadDatagram(&buf, b)
+		if err != nil {
+			t.Fatalf("ReadDatagram() = %v", err)
+		}
+		if got := string(b[:n]); got != want {
+			t.Errorf("ReadDatagram() read %q, expected %q", got, want)
+		}
+	}
+
+	if err := internal.WriteDatagram(&buf, make([]byte, internal.MaxDatagramSize+1)); err == nil {
+		t.Errorf("WriteDatagram() of oversized datagram returned nil error")
+	}
+}
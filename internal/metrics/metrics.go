@@ -0,0 +1,339 @@
+// Package metrics implements counters, gauges and histograms which are
+// exposed in the Prometheus text format.
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// NewRegistry creates a new Registry.
+func NewRegistry() *Registry {
+	return &Registry{}
+}
+
+// Registry is a set of metrics, implements http.Handler to serve them in the
+// Prometheus text format.
+type Registry struct {
+	mu       sync.Mutex // protects metrics and included
+	metrics  []metric
+	included []*Registry
+}
+
+// metric is implemented by each type of metric.
+type metric interface {
+	describe() *desc
+	write(w *bufio.Writer)
+}
+
+func (r *Registry) register(m metric) {
+	defer r.mu.Unlock()
+	r.mu.Lock()
+
+	r.metrics = append(r.metrics, m)
+}
+
+// Include the metrics of o when serving r.  Including o again has no effect.
+func (r *Registry) Include(o *Registry) {
+	defer r.mu.Unlock()
+	r.mu.Lock()
+
+	for _, x := range r.included {
+		if x == o {
+			return
+		}
+	}
+	r.included = append(r.included, o)
+}
+
+// all returns the metrics in r and the registries it includes.
+func (r *Registry) all() []metric {
+	r.mu.Lock()
+	out := append([]metric(nil), r.metrics...)
+	included := append([]*Registry(nil), r.included...)
+	r.mu.Unlock()
+
+	for _, o := range included {
+		out = append(out, o.all()...)
+	}
+	return out
+}
+
+// ServeHTTP writes the metrics in the registry, implements http.Handler.
+func (r *Registry) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	metrics := r.all()
+
+	sort.Slice(metrics, func(i, j int) bool { return metrics[i].describe().name < metrics[j].describe().name })
+
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	bw := bufio.NewWriter(w)
+	for _, m := range metrics {
+		d := m.describe()
+		fmt.Fprintf(bw, "# HELP %s %s\n", d.name, d.help)
+		fmt.Fprintf(bw, "# TYPE %s %s\n", d.name, d.typ)
+		m.write(bw)
+	}
+	bw.Flush()
+}
+
+// desc describes a metric.
+type desc struct {
+	name, help, typ string
+	labels          []string
+}
+
+// Value is a float64 which can be updated atomically.
+type Value struct {
+	bits uint64
+}
+
+// Add x to the value.
+func (v *Value) Add(x float64) {
+	for {
+		old := atomic.LoadUint64(&v.bits)
+		if atomic.CompareAndSwapUint64(&v.bits, old, math.Float64bits(math.Float64frombits(old)+x)) {
+			return
+		}
+	}
+}
+
+// Inc adds 1 to the value.
+func (v *Value) Inc() { v.Add(1) }
+
+// Dec subtracts 1 from the value.
+func (v *Value) Dec() { v.Add(-1) }
+
+// Set the value to x.
+func (v *Value) Set(x float64) { atomic.StoreUint64(&v.bits, math.Float64bits(x)) }
+
+// Get the value.
+func (v *Value) Get() float64 { return math.Float64frombits(atomic.LoadUint64(&v.bits)) }
+
+// series is a set of label values and the state for them.
+type series struct {
+	values []string
+	state  interface{}
+}
+
+// seriesMap maps label values to series.
+type seriesMap struct {
+	desc
+
+	mu     sync.Mutex // protects series
+	series map[string]*series
+}
+
+func newSeriesMap(name, help, typ string, labels []string) seriesMap {
+	return seriesMap{
+		desc: desc{
+			name:   name,
+			help:   help,
+			typ:    typ,
+			labels: labels,
+		},
+		series: make(map[string]*series),
+	}
+}
+
+func (m *seriesMap) describe() *desc { return &m.desc }
+
+// get the series for values, creating it using newState if it doesn't exist.
+func (m *seriesMap) get(values []string, newState func() interface{}) interface{} {
+	if len(values) != len(m.labels) {
+		panic(fmt.Sprintf("metrics: %s has %d labels, got %d values", m.name, len(m.labels), len(values)))
+	}
+	key := strings.Join(values, "\xff")
+
+	defer m.mu.Unlock()
+	m.mu.Lock()
+
+	s, ok := m.series[key]
+	if !ok {
+		s = &series{
+			values: append([]string(nil), values...),
+			state:  newState(),
+		}
+		m.series[key] = s
+	}
+	return s.state
+}
+
+func (m *seriesMap) delete(values []string) {
+	defer m.mu.Unlock()
+	m.mu.Lock()
+
+	delete(m.series, strings.Join(values, "\xff"))
+}
+
+// sorted returns the series, sorted by label values.
+func (m *seriesMap) sorted() []*series {
+	m.mu.Lock()
+	out := make([]*series, 0, len(m.series))
+	for _, s := range m.series {
+		out = append(out, s)
+	}
+	m.mu.Unlock()
+
+	sort.Slice(out, func(i, j int) bool {
+		return strings.Join(out[i].values, "\xff") < strings.Join(out[j].values, "\xff")
+	})
+	return out
+}
+
+// Vec is a counter or gauge, partitioned by labels.
+type Vec struct {
+	seriesMap
+}
+
+// NewCounter creates a counter in r, partitioned by labels.
+func NewCounter(r *Registry, name, help string, labels ...string) *Vec {
+	v := &Vec{newSeriesMap(name, help, "counter", labels)}
+	r.register(v)
+	return v
+}
+
+// NewGauge creates a gauge in r, partitioned by labels.
+func NewGauge(r *Registry, name, help string, labels ...string) *Vec {
+	v := &Vec{newSeriesMap(name, help, "gauge", labels)}
+	r.register(v)
+	return v
+}
+
+// With returns the value for the label values (in the order of the labels).
+func (v *Vec) With(values ...string) *Value {
+	return v.get(values, func() interface{} { return &Value{} }).(*Value)
+}
+
+// Delete the value for the label values.
+func (v *Vec) Delete(values ...string) {
+	v.delete(values)
+}
+
+func (v *Vec) write(w *bufio.Writer) {
+	for _, s := range v.sorted() {
+		writeSample(w, v.name, v.labels, s.values, "", "", s.state.(*Value).Get())
+	}
+}
+
+// NewGaugeFunc creates a gauge in r, whose value is returned by f.
+func NewGaugeFunc(r *Registry, name, help string, f func() float64) {
+	r.register(&gaugeFunc{
+		d: desc{name: name, help: help, typ: "gauge"},
+		f: f,
+	})
+}
+
+type gaugeFunc struct {
+	d desc
+	f func() float64
+}
+
+func (g *gaugeFunc) describe() *desc { return &g.d }
+
+func (g *gaugeFunc) write(w *bufio.Writer) {
+	writeSample(w, g.d.name, nil, nil, "", "", g.f())
+}
+
+// DurationBuckets are histogram buckets (in seconds) suitable for the
+// durations of requests and streams.
+var DurationBuckets = []float64{.01, .1, 1, 10, 60, 600, 3600, 6 * 3600, 24 * 3600}
+
+// Histogram counts observations in buckets, partitioned by labels.
+type Histogram struct {
+	seriesMap
+	buckets []float64
+}
+
+type histogram struct {
+	mu     sync.Mutex
+	counts []uint64 // per bucket (not cumulative)
+	count  uint64
+	sum    float64
+}
+
+// NewHistogram creates a histogram in r with (sorted) buckets, partitioned
+// by labels.
+func NewHistogram(r *Registry, name, help string, buckets []float64, labels ...string) *Histogram {
+	h := &Histogram{
+		seriesMap: newSeriesMap(name, help, "histogram", labels),
+		buckets:   buckets,
+	}
+	r.register(h)
+	return h
+}
+
+// Observe x for the label values (in the order of the labels).
+func (h *Histogram) Observe(x float64, values ...string) {
+	s := h.get(values, func() interface{} {
+		return &histogram{counts: make([]uint64, len(h.buckets))}
+	}).(*histogram)
+
+	defer s.mu.Unlock()
+	s.mu.Lock()
+
+	if i := sort.SearchFloat64s(h.buckets, x); i < len(h.buckets) {
+		s.counts[i]++
+	}
+	s.count++
+	s.sum += x
+}
+
+func (h *Histogram) write(w *bufio.Writer) {
+	for _, s := range h.sorted() {
+		hs := s.state.(*histogram)
+		hs.mu.Lock()
+		var n uint64
+		for i, b := range h.buckets {
+			n += hs.counts[i]
+			writeSample(w, h.name+"_bucket", h.labels, s.values, "le", formatFloat(b), float64(n))
+		}
+		writeSample(w, h.name+"_bucket", h.labels, s.values, "le", "+Inf", float64(hs.count))
+		writeSample(w, h.name+"_sum", h.labels, s.values, "", "", hs.sum)
+		writeSample(w, h.name+"_count", h.labels, s.values, "", "", float64(hs.count))
+		hs.mu.Unlock()
+	}
+}
+
+// writeSample writes a sample line, with an optional extra label.
+func writeSample(w *bufio.Writer, name string, labels, values []string, extraLabel, extraValue string, x float64) {
+	w.WriteString(name)
+	if len(labels) > 0 || extraLabel != "" {
+		w.WriteByte('{')
+		for i, l := range labels {
+			if i > 0 {
+				w.WriteByte(',')
+			}
+			fmt.Fprintf(w, `%s="%s"`, l, labelEscaper.Replace(values[i]))
+		}
+		if extraLabel != "" {
+			if len(labels) > 0 {
+				w.WriteByte(',')
+			}
+			fmt.Fprintf(w, "%s=%q", extraLabel, extraValue)
+		}
+		w.WriteByte('}')
+	}
+	w.WriteByte(' ')
+	w.WriteString(formatFloat(x))
+	w.WriteByte('\n')
+}
+
+// labelEscaper escapes label values.
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func formatFloat(x float64) string {
+	switch {
+	case math.IsInf(x, 1):
+		return "+Inf"
+	case math.IsInf(x, -1):
+		return "-Inf"
+	}
+	return strconv.FormatFloat(x, 'g', -1, 64)
+}
@@ -0,0 +1,80 @@
+package metrics_test
+
+import (
+	"io/ioutil"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/dhowden/mindmeld/internal/metrics"
+)
+
+func TestRegistry(t *testing.T) {
+	r := metrics.NewRegistry()
+
+	c := metrics.NewCounter(r, "test_bytes_total", "Bytes.", "service", "direction")
+	c.With("web", "up").Add(10)
+	c.With("web", "down").Add(5)
+	c.With(`a"b`, "up").Inc()
+
+	g := metrics.NewGauge(r, "test_active", "Active.")
+	g.With().Inc()
+	g.With().Inc()
+	g.With().Dec()
+
+	h := metrics.NewHistogram(r, "test_duration_seconds", "Duration.", []float64{1, 10}, "code")
+	h.Observe(0.5, "OK")
+	h.Observe(5, "OK")
+	h.Observe(50, "OK")
+
+	metrics.NewGaugeFunc(r, "test_func", "Func.", func() float64 { return 3 })
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/metrics", nil))
+	got, _ := ioutil.ReadAll(w.Body)
+
+	want := `# HELP test_active Active.
+# TYPE test_active gauge
+test_active 1
+# HELP test_bytes_total Bytes.
+# TYPE test_bytes_total counter
+test_bytes_total{service="a\"b",direction="up"} 1
+test_bytes_total{service="web",direction="down"} 5
+test_bytes_total{service="web",direction="up"} 10
+# HELP test_duration_seconds Duration.
+# TYPE test_duration_seconds histogram
+test_duration_seconds_bucket{code="OK",le="1"} 1
+test_duration_seconds_bucket{code="OK",le="10"} 2
+test_duration_seconds_bucket{code="OK",le="+Inf"} 3
+test_duration_seconds_sum{code="OK"} 55.5
+test_duration_seconds_count{code="OK"} 3
+# HELP test_func Func.
+# TYPE test_func gauge
+test_func 3
+`
+	if string(got) != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestRegistryInclude(t *testing.T) {
+	r, o := metrics.NewRegistry(), metrics.NewRegistry()
+	metrics.NewGauge(r, "test_b", "B.").With().Inc()
+	metrics.NewGauge(o, "test_a", "A.").With().Inc()
+	r.Include(o)
+	r.Include(o)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/metrics", nil))
+	got, _ := ioutil.ReadAll(w.Body)
+
+	want := `# HELP test_a A.
+# TYPE test_a gauge
+test_a 1
+# HELP test_b B.
+# TYPE test_b gauge
+test_b 1
+`
+	if string(got) != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
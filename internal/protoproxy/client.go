@@ -20,6 +20,6 @@ func Dial(cc *grpc.ClientConn) (*Conn, error) {
 		return nil, fmt.Errorf("could not init proxy connection: %w", err)
 	}
 
-	c := newConn(x)
+	c := newConn(x, nil)
 	return c, nil
 }
@@ -2,9 +2,9 @@ package protoproxy
 
 import (
 	"bytes"
-	"errors"
 	"io"
 	"net"
+	"os"
 	"sync"
 	"time"
 
@@ -28,12 +28,14 @@ type PayloadStream interface {
 	Recv() (*pb.Payload, error)
 }
 
-func newConn(s PayloadStream) *Conn {
+// newConn creates a Conn using s, with bytes counted by m (which may be nil).
+func newConn(s PayloadStream, m *proxyMetrics) *Conn {
 	c := &Conn{
-		r: newReadBuffer(bufferSize),
-		w: newWriteBuffer(bufferSize),
+		r:      newReadBuffer(bufferSize),
+		w:      newWriteBuffer(bufferSize),
+		closed: make(chan struct{}),
 	}
-	go c.loop(s)
+	go c.loop(s, m)
 	return c
 }
 
@@ -41,14 +43,48 @@ func newConn(s PayloadStream) *Conn {
 type Conn struct {
 	r *readBuffer
 	w *writeBuffer
+
+	local, remote net.Addr
+
+	closeOnce sync.Once
+	closed    chan struct{}
+}
+
+// SetAddr sets the addresses returned by LocalAddr and RemoteAddr.  Must be
+// called before the Conn is shared.
+func (c *Conn) SetAddr(local, remote net.Addr) {
+	c.local = local
+	c.remote = remote
+}
+
+// LocalAddr returns the local address set by SetAddr (nil if not set),
+// implements net.Conn.
+func (c *Conn) LocalAddr() net.Addr { return c.local }
+
+// RemoteAddr returns the remote address set by SetAddr (nil if not set),
+// implements net.Conn.
+func (c *Conn) RemoteAddr() net.Addr { return c.remote }
+
+// SetDeadline sets the read and write deadlines, implements net.Conn.
+func (c *Conn) SetDeadline(t time.Time) error {
+	c.r.deadline.set(t)
+	c.w.deadline.set(t)
+	return nil
 }
 
-func (c *Conn) LocalAddr() net.Addr  { return nil }
-func (c *Conn) RemoteAddr() net.Addr { return nil }
+// SetReadDeadline sets the deadline for Read calls, implements net.Conn.
+// Reads which exceed the deadline return os.ErrDeadlineExceeded.
+func (c *Conn) SetReadDeadline(t time.Time) error {
+	c.r.deadline.set(t)
+	return nil
+}
 
-func (c *Conn) SetDeadline(_ time.Time) error      { return errors.New("not supported") }
-func (c *Conn) SetReadDeadline(_ time.Time) error  { return errors.New("not supported") }
-func (c *Conn) SetWriteDeadline(_ time.Time) error { return errors.New("not supported") }
+// SetWriteDeadline sets the deadline for Write calls, implements net.Conn.
+// Writes which exceed the deadline return os.ErrDeadlineExceeded.
+func (c *Conn) SetWriteDeadline(t time.Time) error {
+	c.w.deadline.set(t)
+	return nil
+}
 
 // Write some bytes, implements io.Writer. Translates to a Send on the PayloadStream.
 func (c *Conn) Write(b []byte) (n int, err error) { return c.w.Write(b) }
@@ -60,22 +96,65 @@ func (c *Conn) Read(b []byte) (n int, err error) { return c.r.Read(b) }
 func (c *Conn) Close() error {
 	c.w.Close()
 	c.r.Close()
+	c.closeOnce.Do(func() {
+		close(c.closed)
+	})
 	return nil
 }
 
-func (c *Conn) loop(s PayloadStream) {
+// CloseWrite shuts down the writing side of the connection.  Buffered writes
+// are sent, followed by an end-of-stream payload, after which the peer will
+// read io.EOF.  Reads are unaffected.
+func (c *Conn) CloseWrite() error {
+	return c.w.Close()
+}
+
+// CloseRead shuts down the reading side of the connection.  Any further
+// data received is discarded.  Writes are unaffected.
+func (c *Conn) CloseRead() error {
+	return c.r.Close()
+}
+
+func (c *Conn) loop(s PayloadStream, m *proxyMetrics) {
+	sent, received := m.byteCounters()
+
+	// finished is closed when the peer has finished sending.
+	finished := make(chan struct{})
 	go func() {
+		eos := false
 		for {
 			p, err := s.Recv()
-			c.r.append(p.GetData(), err)
 			if err != nil {
+				if !eos {
+					c.r.append(nil, err)
+					close(finished)
+				}
 				return
 			}
+
+			// Keep receiving after the end of the stream, until the
+			// stream is closed.
+			if eos {
+				continue
+			}
+			if _, ok := p.GetPayload().(*pb.Payload_Close); ok {
+				eos = true
+				c.r.append(nil, io.EOF)
+				close(finished)
+				continue
+			}
+			received.Add(float64(len(p.GetData())))
+			c.r.append(p.GetData(), nil)
 		}
 	}()
 
-	for data := range c.w.writes() {
-		if err := s.Send(&pb.Payload{
+	var err error
+	for {
+		data, ok := c.w.next()
+		if !ok {
+			break
+		}
+		if err = s.Send(&pb.Payload{
 			Payload: &pb.Payload_Data{
 				Data: data,
 			},
@@ -83,13 +162,29 @@ func (c *Conn) loop(s PayloadStream) {
 			c.w.setErr(err)
 			break
 		}
+		sent.Add(float64(len(data)))
+	}
+
+	if err == nil {
+		// Mark the end of the stream, so that the peer can keep sending
+		// until the connection is closed (or the peer has also finished).
+		if err := s.Send(&pb.Payload{
+			Payload: &pb.Payload_Close{Close: &pb.Close{}},
+		}); err == nil {
+			select {
+			case <-c.closed:
+			case <-finished:
+			}
+		}
 	}
 	s.CloseSend() // ignore the error for now
 }
 
 func newReadBuffer(n int) *readBuffer {
 	return &readBuffer{
-		ch: make(chan readPayload, n),
+		ch:       make(chan readPayload, n),
+		deadline: newDeadline(),
+		closed:   make(chan struct{}),
 	}
 }
 
@@ -99,75 +194,126 @@ type readPayload struct {
 }
 
 type readBuffer struct {
-	ch chan readPayload
+	ch       chan readPayload
+	deadline *deadline
 
-	done bool
+	closeOnce sync.Once
+	closed    chan struct{}
 
-	r *bytes.Reader
+	// Only accessed by Read.
+	err error
+	r   *bytes.Reader
 }
 
 func (rb *readBuffer) append(b []byte, err error) {
-	rb.ch <- readPayload{
+	select {
+	case rb.ch <- readPayload{
 		data: b,
 		err:  err,
+	}:
+	case <-rb.closed:
 	}
 }
 
 func (rb *readBuffer) Read(b []byte) (n int, err error) {
-	if rb.done {
+	if isClosed(rb.closed) {
 		return 0, io.EOF
 	}
+	if isClosed(rb.deadline.wait()) {
+		return 0, os.ErrDeadlineExceeded
+	}
+	if rb.err != nil {
+		return 0, rb.err
+	}
+
+	for rb.r == nil {
+		select {
+		case rp := <-rb.ch:
+			if rp.err != nil {
+				rb.err = rp.err
+				return 0, rp.err
+			}
+			if len(rp.data) > 0 {
+				rb.r = bytes.NewReader(rp.data)
+			}
 
-	if rb.r == nil {
-		rp := <-rb.ch
-		if rp.err != nil {
-			rb.done = true
-			return 0, err
+		case <-rb.deadline.wait():
+			return 0, os.ErrDeadlineExceeded
+
+		case <-rb.closed:
+			return 0, io.EOF
 		}
-		rb.r = bytes.NewReader(rp.data)
 	}
 
-	n, err = rb.r.Read(b)
-	if err == io.EOF {
+	n, _ = rb.r.Read(b)
+	if rb.r.Len() == 0 {
 		rb.r = nil
 	}
 	return n, nil
 }
 
 func (rb *readBuffer) Close() error {
-	rb.done = true
+	rb.closeOnce.Do(func() {
+		close(rb.closed)
+	})
 	return nil
 }
 
 func newWriteBuffer(n int) *writeBuffer {
 	return &writeBuffer{
-		ch:     make(chan []byte, n),
-		errCh:  make(chan error, 1),
-		doneCh: make(chan struct{}),
+		ch:       make(chan []byte, n),
+		deadline: newDeadline(),
+		failed:   make(chan struct{}),
+		doneCh:   make(chan struct{}),
 	}
 }
 
 type writeBuffer struct {
-	ch    chan []byte
-	errCh chan error
+	ch       chan []byte
+	deadline *deadline
+
+	errOnce sync.Once
+	err     error // set before failed is closed
+	failed  chan struct{}
 
-	done     bool
 	doneOnce sync.Once
 	doneCh   chan struct{}
 }
 
 func (wb *writeBuffer) setErr(err error) {
-	wb.errCh <- err
-	close(wb.errCh)
+	wb.errOnce.Do(func() {
+		wb.err = err
+		close(wb.failed)
+	})
 }
 
-func (wb *writeBuffer) writes() <-chan []byte {
-	return wb.ch
+// next returns the next buffered write, or false once the buffer has been
+// closed and all buffered writes have been returned.
+func (wb *writeBuffer) next() ([]byte, bool) {
+	select {
+	case b := <-wb.ch:
+		return b, true
+	case <-wb.doneCh:
+	}
+
+	select {
+	case b := <-wb.ch:
+		return b, true
+	default:
+		return nil, false
+	}
 }
 
 func (wb *writeBuffer) Write(b []byte) (n int, err error) {
-	if wb.done {
+	select {
+	case <-wb.failed:
+		return 0, wb.err
+	case <-wb.doneCh:
 		return 0, io.EOF
+	default:
+	}
+	if isClosed(wb.deadline.wait()) {
+		return 0, os.ErrDeadlineExceeded
 	}
 
 	// Must not retain the original value beyond the call
@@ -179,25 +325,20 @@ func (wb *writeBuffer) Write(b []byte) (n int, err error) {
 	case wb.ch <- b2:
 		return len(b2), nil
 
-	case err := <-wb.errCh:
-		wb.close()
-		return 0, err
+	case <-wb.failed:
+		return 0, wb.err
 
 	case <-wb.doneCh:
-		wb.close()
 		return 0, io.EOF
+
+	case <-wb.deadline.wait():
+		return 0, os.ErrDeadlineExceeded
 	}
 }
 
-func (wb *writeBuffer) close() {
-	wb.done = true
+func (wb *writeBuffer) Close() error {
 	wb.doneOnce.Do(func() {
 		close(wb.doneCh)
-		close(wb.ch)
 	})
-}
-
-func (wb *writeBuffer) Close() error {
-	wb.close()
 	return nil
 }
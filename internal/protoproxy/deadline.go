@@ -0,0 +1,72 @@
+package protoproxy
+
+import (
+	"sync"
+	"time"
+)
+
+// deadline signals when a point in time has passed by closing a channel.
+// Adapted from the deadline implementation of net.Pipe.
+type deadline struct {
+	mu     sync.Mutex // protects timer and cancel
+	timer  *time.Timer
+	cancel chan struct{} // closed when the deadline is exceeded
+}
+
+func newDeadline() *deadline {
+	return &deadline{
+		cancel: make(chan struct{}),
+	}
+}
+
+// set the deadline.  A zero value for t means no deadline.  Once exceeded
+// the deadline can be extended by setting a time in the future.
+func (d *deadline) set(t time.Time) {
+	defer d.mu.Unlock()
+	d.mu.Lock()
+
+	if d.timer != nil && !d.timer.Stop() {
+		<-d.cancel // wait for the timer to close cancel
+	}
+	d.timer = nil
+
+	exceeded := isClosed(d.cancel)
+	if t.IsZero() {
+		if exceeded {
+			d.cancel = make(chan struct{})
+		}
+		return
+	}
+
+	if dur := time.Until(t); dur > 0 {
+		if exceeded {
+			d.cancel = make(chan struct{})
+		}
+		cancel := d.cancel
+		d.timer = time.AfterFunc(dur, func() {
+			close(cancel)
+		})
+		return
+	}
+
+	if !exceeded {
+		close(d.cancel)
+	}
+}
+
+// wait returns a channel which is closed when the deadline is exceeded.
+func (d *deadline) wait() <-chan struct{} {
+	defer d.mu.Unlock()
+	d.mu.Lock()
+
+	return d.cancel
+}
+
+func isClosed(ch <-chan struct{}) bool {
+	select {
+	case <-ch:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,119 @@
+package protoproxy_test
+
+import (
+	"errors"
+	"io"
+	"net"
+	"os"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+
+	"github.com/dhowden/mindmeld/internal/protoproxy"
+
+	"github.com/dhowden/mindmeld/pb"
+)
+
+// isTimeout returns true if err is a net.Error which is a timeout.
+func isTimeout(err error) bool {
+	var ne net.Error
+	return errors.As(err, &ne) && ne.Timeout()
+}
+
+func TestReadDeadline(t *testing.T) {
+	const msg = "hello world!"
+
+	ps := protoproxy.NewServer()
+	accepted := make(chan net.Conn, 1)
+	go func() {
+		c, err := ps.Accept()
+		if err != nil {
+			t.Errorf("Accept(): %v", err)
+			return
+		}
+		accepted <- c
+	}()
+
+	s := grpc.NewServer()
+	pb.RegisterProxyServiceServer(s, ps)
+	ts := NewTestServer(s)
+	defer ts.Stop(t)
+
+	c, err := protoproxy.Dial(ts.ClientConn(t))
+	if err != nil {
+		t.Fatalf("could not dial server: %v", err)
+	}
+	defer c.Close()
+
+	c.SetReadDeadline(time.Now().Add(20 * time.Millisecond))
+	buf := make([]byte, 32)
+	if _, err := c.Read(buf); !isTimeout(err) || !errors.Is(err, os.ErrDeadlineExceeded) {
+		t.Fatalf("Read() = %v, want timeout", err)
+	}
+
+	// Deadline in the past fails immediately.
+	c.SetReadDeadline(time.Now().Add(-time.Second))
+	if _, err := c.Read(buf); !isTimeout(err) {
+		t.Fatalf("Read() = %v, want timeout", err)
+	}
+
+	// Clearing the deadline allows reads again.
+	c.SetReadDeadline(time.Time{})
+	sc := <-accepted
+	defer sc.Close()
+	if _, err := io.WriteString(sc, msg); err != nil {
+		t.Fatalf("write failed: %v", err)
+	}
+
+	n, err := io.ReadFull(c, buf[:len(msg)])
+	if err != nil {
+		t.Fatalf("read failed (after %d bytes): %v", n, err)
+	}
+	if got := string(buf[:n]); got != msg {
+		t.Errorf("got %q, want %q", got, msg)
+	}
+}
+
+func TestWriteDeadline(t *testing.T) {
+	// Use a multiplexed connection, which limits how much can be written
+	// before the peer reads.
+	ps := protoproxy.NewServer()
+	go func() {
+		c, err := ps.Accept()
+		if err != nil {
+			t.Errorf("Accept(): %v", err)
+			return
+		}
+		defer c.Close()
+		time.Sleep(time.Second) // never read
+	}()
+
+	s := grpc.NewServer()
+	pb.RegisterProxyServiceServer(s, ps)
+	ts := NewTestServer(s)
+	defer ts.Stop(t)
+
+	sess, err := protoproxy.DialSession(ts.ClientConn(t))
+	if err != nil {
+		t.Fatalf("could not dial session: %v", err)
+	}
+	defer sess.Close()
+
+	c, err := sess.Open()
+	if err != nil {
+		t.Fatalf("Open(): %v", err)
+	}
+	defer c.Close()
+
+	c.SetWriteDeadline(time.Now().Add(50 * time.Millisecond))
+	buf := make([]byte, 64*1024)
+	for i := 0; i < 1000; i++ {
+		if _, err = c.Write(buf); err != nil {
+			break
+		}
+	}
+	if !isTimeout(err) {
+		t.Errorf("Write() = %v, want timeout", err)
+	}
+}
@@ -2,7 +2,9 @@ package protoproxy_test
 
 import (
 	"bytes"
+	"context"
 	"io"
+	"io/ioutil"
 	"testing"
 	"time"
 
@@ -98,3 +100,99 @@ func TestProxyClientRead(t *testing.T) {
 		t.Errorf("got %q, want %q", got.String(), msg)
 	}
 }
+
+func TestProxyHalfClose(t *testing.T) {
+	const request, response = "request", "response"
+
+	ps := protoproxy.NewServer()
+	go func() {
+		c, err := ps.Accept()
+		if err != nil {
+			t.Errorf("Accept(): %v", err)
+			return
+		}
+		defer c.Close()
+
+		// Read until the client closes its writing side, then respond.
+		got, err := ioutil.ReadAll(c)
+		if err != nil {
+			t.Errorf("server read failed: %v", err)
+		}
+		if string(got) != request {
+			t.Errorf("server got %q, want %q", got, request)
+		}
+
+		if _, err := io.WriteString(c, response); err != nil {
+			t.Errorf("server write failed: %v", err)
+		}
+		c.(*protoproxy.Conn).CloseWrite()
+	}()
+
+	s := grpc.NewServer()
+	pb.RegisterProxyServiceServer(s, ps)
+	ts := NewTestServer(s)
+	defer ts.Stop(t)
+
+	c, err := protoproxy.Dial(ts.ClientConn(t))
+	if err != nil {
+		t.Fatalf("could not dial server: %v", err)
+	}
+	defer c.Close()
+
+	if _, err := io.WriteString(c, request); err != nil {
+		t.Fatalf("write failed: %v", err)
+	}
+	c.CloseWrite()
+
+	if _, err := io.WriteString(c, request); err == nil {
+		t.Errorf("expected error writing after CloseWrite")
+	}
+
+	got, err := ioutil.ReadAll(c)
+	if err != nil {
+		t.Fatalf("read failed: %v", err)
+	}
+	if string(got) != response {
+		t.Errorf("got %q, want %q", got, response)
+	}
+}
+
+func TestProxyHalfCloseBothSides(t *testing.T) {
+	ps := protoproxy.NewServer()
+	go func() {
+		c, err := ps.Accept()
+		if err != nil {
+			t.Errorf("Accept(): %v", err)
+			return
+		}
+
+		// Finish writing but never Close: the stream should still end once
+		// the client has finished too.
+		c.(*protoproxy.Conn).CloseWrite()
+	}()
+
+	s := grpc.NewServer()
+	pb.RegisterProxyServiceServer(s, ps)
+	ts := NewTestServer(s)
+	defer ts.Stop(t)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	x, err := pb.NewProxyServiceClient(ts.ClientConn(t)).ProxyConnection(ctx)
+	if err != nil {
+		t.Fatalf("ProxyConnection() = %v", err)
+	}
+	if err := x.Send(&pb.Payload{Payload: &pb.Payload_Close{Close: &pb.Close{}}}); err != nil {
+		t.Fatalf("Send() = %v", err)
+	}
+
+	for {
+		_, err := x.Recv()
+		if err == io.EOF {
+			return
+		}
+		if err != nil {
+			t.Fatalf("Recv() = %v, expected io.EOF", err)
+		}
+	}
+}
@@ -0,0 +1,31 @@
+package protoproxy
+
+import "github.com/dhowden/mindmeld/internal/metrics"
+
+// proxyMetrics are the metrics of the proxy connections accepted by a Server.
+type proxyMetrics struct {
+	streams *metrics.Vec
+	conns   *metrics.Vec
+	bytes   *metrics.Vec
+}
+
+func newProxyMetrics(r *metrics.Registry) *proxyMetrics {
+	return &proxyMetrics{
+		streams: metrics.NewGauge(r, "mindmeld_proxy_streams_active",
+			"Number of active proxy streams accepted, by type (stream or multiplex).", "type"),
+		conns: metrics.NewCounter(r, "mindmeld_proxy_connections_total",
+			"Number of proxy connections accepted."),
+		bytes: metrics.NewCounter(r, "mindmeld_proxy_bytes_total",
+			"Bytes carried by proxy connections, by direction (sent or received).", "direction"),
+	}
+}
+
+// byteCounters returns the values counting bytes sent and received by a
+// connection.  If m is nil (i.e. on the client side) the counts are
+// discarded.
+func (m *proxyMetrics) byteCounters() (sent, received *metrics.Value) {
+	if m == nil {
+		return &metrics.Value{}, &metrics.Value{}
+	}
+	return m.bytes.With("sent"), m.bytes.With("received")
+}
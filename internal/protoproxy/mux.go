@@ -0,0 +1,477 @@
+package protoproxy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+
+	"google.golang.org/grpc"
+
+	"github.com/dhowden/mindmeld/pb"
+)
+
+const (
+	// Number of bytes each side of a multiplexed connection is initially
+	// prepared to receive.
+	initialWindow = 256 * 1024
+
+	// Maximum number of bytes sent in a single data payload on a multiplexed
+	// connection.
+	maxFrameSize = 32 * 1024
+
+	// Number of connections opened by the peer which can wait to be accepted,
+	// after which further connections are reset.
+	acceptBacklog = 64
+)
+
+var (
+	// ErrSessionClosed is returned when using a Session which has been closed.
+	ErrSessionClosed = errors.New("session closed")
+
+	// ErrStreamReset is returned when using a connection which has been reset
+	// by the peer.
+	ErrStreamReset = errors.New("connection reset by peer")
+
+	// errStreamClosed is returned when using a connection after it's closed.
+	errStreamClosed = errors.New("use of closed connection")
+)
+
+// MuxStream is the stream used to carry multiplexed connections.
+type MuxStream interface {
+	Send(*pb.Payload) error
+	Recv() (*pb.Payload, error)
+}
+
+// DialSession creates a Session to the proxy service, which can be used to
+// open many connections over a single stream.
+func DialSession(cc *grpc.ClientConn) (*Session, error) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	ps := pb.NewProxyServiceClient(cc)
+	x, err := ps.MultiplexConnection(ctx)
+	if err != nil {
+		cancel()
+		return nil, fmt.Errorf("could not init multiplexed connection: %w", err)
+	}
+
+	s := newSession(x, nil, nil)
+	s.cancel = cancel
+	return s, nil
+}
+
+func newSession(x MuxStream, accept func(*Conn), m *proxyMetrics) *Session {
+	s := &Session{
+		x:       x,
+		accept:  accept,
+		metrics: m,
+		streams: make(map[uint32]*muxStream),
+		nextID:  1,
+		done:    make(chan struct{}),
+	}
+	if accept != nil {
+		s.acceptCh = make(chan *Conn, acceptBacklog)
+		go s.acceptLoop()
+	}
+	go s.loop()
+	return s
+}
+
+// Session multiplexes many connections over a single MuxStream.  Only the
+// client side of the stream opens connections.
+type Session struct {
+	x      MuxStream
+	cancel context.CancelFunc // nil on the server side
+
+	// accept is called for each connection opened by the peer, in order,
+	// from acceptLoop so that slow accepts don't hold up other connections.
+	accept   func(*Conn)
+	acceptCh chan *Conn
+
+	metrics *proxyMetrics // nil on the client side
+
+	sendMu sync.Mutex // serialises calls to x.Send
+
+	mu      sync.Mutex // protects streams, nextID and err
+	streams map[uint32]*muxStream
+	nextID  uint32
+	err     error
+
+	doneOnce sync.Once
+	done     chan struct{}
+}
+
+// Open a new connection on the session.
+func (s *Session) Open() (*Conn, error) {
+	s.mu.Lock()
+	if s.err != nil {
+		err := s.err
+		s.mu.Unlock()
+		return nil, err
+	}
+	id := s.nextID
+	s.nextID++
+	ms := newMuxStream(s, id)
+	s.streams[id] = ms
+	s.mu.Unlock()
+
+	if err := s.send(&pb.Payload{
+		StreamId: id,
+		Payload:  &pb.Payload_Open{Open: &pb.Open{}},
+	}); err != nil {
+		s.remove(id)
+		return nil, fmt.Errorf("could not open stream: %w", err)
+	}
+	return newConn(ms, s.metrics), nil
+}
+
+// Done returns a channel which is closed when the session ends.
+func (s *Session) Done() <-chan struct{} {
+	return s.done
+}
+
+// Close the session, and all of its connections.
+func (s *Session) Close() error {
+	s.fail(ErrSessionClosed)
+	return nil
+}
+
+func (s *Session) send(p *pb.Payload) error {
+	defer s.sendMu.Unlock()
+	s.sendMu.Lock()
+
+	select {
+	case <-s.done:
+		return s.getErr()
+	default:
+	}
+	return s.x.Send(p)
+}
+
+func (s *Session) getErr() error {
+	defer s.mu.Unlock()
+	s.mu.Lock()
+
+	return s.err
+}
+
+func (s *Session) remove(id uint32) {
+	defer s.mu.Unlock()
+	s.mu.Lock()
+
+	delete(s.streams, id)
+}
+
+// fail ends the session with err, and fails all of its connections.
+func (s *Session) fail(err error) {
+	s.mu.Lock()
+	if s.err == nil {
+		s.err = err
+	}
+	streams := s.streams
+	s.streams = make(map[uint32]*muxStream)
+	s.mu.Unlock()
+
+	for _, ms := range streams {
+		ms.fail(err)
+	}
+
+	s.doneOnce.Do(func() {
+		close(s.done)
+		if s.cancel != nil {
+			s.cancel()
+		}
+	})
+}
+
+func (s *Session) loop() {
+	for {
+		p, err := s.x.Recv()
+		if err != nil {
+			if err == io.EOF {
+				err = ErrSessionClosed
+			}
+			s.fail(err)
+			return
+		}
+
+		id := p.GetStreamId()
+		if _, ok := p.GetPayload().(*pb.Payload_Open); ok {
+			s.open(id)
+			continue
+		}
+
+		s.mu.Lock()
+		ms, ok := s.streams[id]
+		s.mu.Unlock()
+		if !ok {
+			// Stream has already been closed (or never existed).
+			continue
+		}
+
+		switch x := p.GetPayload().(type) {
+		case *pb.Payload_Data:
+			ms.push(x.Data)
+
+		case *pb.Payload_Close:
+			ms.pushEOF()
+
+		case *pb.Payload_Window:
+			ms.addWindow(int(x.Window.GetIncrement()))
+
+		case *pb.Payload_ResetStream:
+			s.remove(id)
+			ms.fail(ErrStreamReset)
+		}
+	}
+}
+
+// open handles a request from the peer to open a new connection.  If too
+// many connections are waiting to be accepted then the connection is reset.
+func (s *Session) open(id uint32) {
+	s.mu.Lock()
+	if _, ok := s.streams[id]; ok || s.accept == nil {
+		s.mu.Unlock()
+		s.fail(fmt.Errorf("invalid open for stream %d", id))
+		return
+	}
+	ms := newMuxStream(s, id)
+	s.streams[id] = ms
+	s.mu.Unlock()
+
+	c := newConn(ms, s.metrics)
+	select {
+	case s.acceptCh <- c:
+	default:
+		c.Close()
+	}
+}
+
+// acceptLoop passes connections opened by the peer to accept.  Connections
+// still waiting when the session ends are closed.
+func (s *Session) acceptLoop() {
+	for {
+		select {
+		case c := <-s.acceptCh:
+			s.accept(c)
+
+		case <-s.done:
+			for {
+				select {
+				case c := <-s.acceptCh:
+					c.Close()
+				default:
+					return
+				}
+			}
+		}
+	}
+}
+
+func newMuxStream(s *Session, id uint32) *muxStream {
+	ms := &muxStream{
+		s:          s,
+		id:         id,
+		sendWindow: initialWindow,
+		recvWindow: initialWindow,
+	}
+	ms.cond = sync.NewCond(&ms.mu)
+	return ms
+}
+
+// muxStream is a single connection on a Session, and implements PayloadStream
+// so that it can be wrapped in a Conn.
+type muxStream struct {
+	s  *Session
+	id uint32
+
+	mu         sync.Mutex
+	cond       *sync.Cond // signalled when any of the below change
+	recv       [][]byte
+	recvEOF    bool
+	recvWindow int // bytes the peer is allowed to send
+	sendWindow int
+	sendClosed bool
+	err        error
+}
+
+// Send implements PayloadStream.  Data is split into frames, waiting for the
+// peer to allow more data to be sent as required.
+func (ms *muxStream) Send(p *pb.Payload) error {
+	if _, ok := p.GetPayload().(*pb.Payload_Close); ok {
+		return ms.closeSend()
+	}
+
+	data := p.GetData()
+	for len(data) > 0 {
+		n, err := ms.reserve(len(data))
+		if err != nil {
+			return err
+		}
+
+		if err := ms.s.send(&pb.Payload{
+			StreamId: ms.id,
+			Payload:  &pb.Payload_Data{Data: data[:n]},
+		}); err != nil {
+			return err
+		}
+		data = data[n:]
+	}
+	return nil
+}
+
+// reserve waits until the send window is open, and then reserves up to n bytes
+// from it.
+func (ms *muxStream) reserve(n int) (int, error) {
+	defer ms.mu.Unlock()
+	ms.mu.Lock()
+
+	for ms.sendWindow == 0 && ms.err == nil {
+		ms.cond.Wait()
+	}
+	if ms.err != nil {
+		return 0, ms.err
+	}
+
+	if n > ms.sendWindow {
+		n = ms.sendWindow
+	}
+	if n > maxFrameSize {
+		n = maxFrameSize
+	}
+	ms.sendWindow -= n
+	return n, nil
+}
+
+// Recv implements PayloadStream.  The peer is allowed to send more data as
+// data is received.
+func (ms *muxStream) Recv() (*pb.Payload, error) {
+	ms.mu.Lock()
+	for len(ms.recv) == 0 && !ms.recvEOF && ms.err == nil {
+		ms.cond.Wait()
+	}
+	if len(ms.recv) == 0 {
+		defer ms.mu.Unlock()
+		// A peer which sent the end of the stream before resetting it
+		// closed the connection cleanly.
+		if ms.recvEOF {
+			return nil, io.EOF
+		}
+		return nil, ms.err
+	}
+
+	data := ms.recv[0]
+	ms.recv = ms.recv[1:]
+	ms.recvWindow += len(data)
+	ms.mu.Unlock()
+
+	if err := ms.s.send(&pb.Payload{
+		StreamId: ms.id,
+		Payload:  &pb.Payload_Window{Window: &pb.Window{Increment: uint32(len(data))}},
+	}); err != nil {
+		return nil, err
+	}
+	return &pb.Payload{
+		StreamId: ms.id,
+		Payload:  &pb.Payload_Data{Data: data},
+	}, nil
+}
+
+// CloseSend implements PayloadStream, and is called when the Conn has
+// finished with the stream.  Unless both sides have sent the end of the
+// stream, it is reset so that neither side waits for the other.
+func (ms *muxStream) CloseSend() error {
+	ms.mu.Lock()
+	done := (ms.sendClosed && ms.recvEOF) || ms.err != nil
+	if ms.err == nil {
+		ms.err = errStreamClosed
+	}
+	ms.cond.Broadcast()
+	ms.mu.Unlock()
+
+	if done {
+		return nil
+	}
+	return ms.reset()
+}
+
+// reset removes the stream from the session, and tells the peer to do the
+// same.  Must be called after the stream has failed.
+func (ms *muxStream) reset() error {
+	ms.s.remove(ms.id)
+	return ms.s.send(&pb.Payload{
+		StreamId: ms.id,
+		Payload:  &pb.Payload_ResetStream{ResetStream: &pb.Reset{}},
+	})
+}
+
+// closeSend sends the end of the stream to the peer.
+func (ms *muxStream) closeSend() error {
+	ms.mu.Lock()
+	if ms.sendClosed || ms.err != nil {
+		ms.mu.Unlock()
+		return nil
+	}
+	ms.sendClosed = true
+	done := ms.recvEOF
+	ms.mu.Unlock()
+
+	if done {
+		ms.s.remove(ms.id)
+	}
+	return ms.s.send(&pb.Payload{
+		StreamId: ms.id,
+		Payload:  &pb.Payload_Close{Close: &pb.Close{}},
+	})
+}
+
+// push data received from the peer.  If the peer sends more than it has
+// been allowed to then the stream is reset.
+func (ms *muxStream) push(data []byte) {
+	ms.mu.Lock()
+	if ms.err != nil {
+		ms.mu.Unlock()
+		return
+	}
+	if len(data) > ms.recvWindow {
+		ms.mu.Unlock()
+		ms.fail(fmt.Errorf("stream %d exceeded its receive window", ms.id))
+		ms.reset()
+		return
+	}
+	ms.recvWindow -= len(data)
+	ms.recv = append(ms.recv, data)
+	ms.cond.Broadcast()
+	ms.mu.Unlock()
+}
+
+func (ms *muxStream) pushEOF() {
+	ms.mu.Lock()
+	ms.recvEOF = true
+	done := ms.sendClosed
+	ms.cond.Broadcast()
+	ms.mu.Unlock()
+
+	if done {
+		ms.s.remove(ms.id)
+	}
+}
+
+func (ms *muxStream) addWindow(n int) {
+	defer ms.mu.Unlock()
+	ms.mu.Lock()
+
+	ms.sendWindow += n
+	ms.cond.Broadcast()
+}
+
+func (ms *muxStream) fail(err error) {
+	defer ms.mu.Unlock()
+	ms.mu.Lock()
+
+	if ms.err == nil {
+		ms.err = err
+	}
+	ms.cond.Broadcast()
+}
@@ -0,0 +1,206 @@
+package protoproxy_test
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"sync"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+
+	"github.com/dhowden/mindmeld/internal/protoproxy"
+
+	"github.com/dhowden/mindmeld/pb"
+)
+
+// echo accepts connections from ps, reads a 4 byte length and then echos back
+// that many bytes before closing the connection.
+func echo(t *testing.T, ps *protoproxy.Server) {
+	for {
+		c, err := ps.Accept()
+		if err != nil {
+			t.Errorf("Accept(): %v", err)
+			return
+		}
+
+		go func() {
+			defer c.Close()
+
+			var n uint32
+			if err := binary.Read(c, binary.LittleEndian, &n); err != nil {
+				t.Errorf("could not read length: %v", err)
+				return
+			}
+			if _, err := io.CopyN(c, c, int64(n)); err != nil {
+				t.Errorf("echo copy failed: %v", err)
+			}
+		}()
+	}
+}
+
+func TestSession(t *testing.T) {
+	ps := protoproxy.NewServer()
+	go echo(t, ps)
+
+	s := grpc.NewServer()
+	pb.RegisterProxyServiceServer(s, ps)
+	ts := NewTestServer(s)
+	defer ts.Stop(t)
+
+	cc := ts.ClientConn(t)
+	sess, err := protoproxy.DialSession(cc)
+	if err != nil {
+		t.Fatalf("could not dial session: %v", err)
+	}
+	defer sess.Close()
+
+	// Larger than the initial window, so that flow control kicks in.
+	msg := make([]byte, 1024*1024)
+	rand.Read(msg)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+
+			c, err := sess.Open()
+			if err != nil {
+				t.Errorf("Open(): %v", err)
+				return
+			}
+
+			want := append([]byte(fmt.Sprintf("conn %d:", i)), msg...)
+			go func() {
+				if err := binary.Write(c, binary.LittleEndian, uint32(len(want))); err != nil {
+					t.Errorf("write failed: %v", err)
+				}
+				if _, err := c.Write(want); err != nil {
+					t.Errorf("write failed: %v", err)
+				}
+			}()
+
+			got := make([]byte, len(want))
+			if _, err := io.ReadFull(c, got); err != nil {
+				t.Errorf("read failed: %v", err)
+			}
+			if !bytes.Equal(got, want) {
+				t.Errorf("conn %d: got %d bytes, want %d bytes", i, len(got), len(want))
+			}
+			c.Close()
+		}(i)
+	}
+	wg.Wait()
+}
+
+func TestSessionClose(t *testing.T) {
+	ps := protoproxy.NewServer()
+
+	s := grpc.NewServer()
+	pb.RegisterProxyServiceServer(s, ps)
+	ts := NewTestServer(s)
+	defer ts.Stop(t)
+
+	cc := ts.ClientConn(t)
+	sess, err := protoproxy.DialSession(cc)
+	if err != nil {
+		t.Fatalf("could not dial session: %v", err)
+	}
+
+	sess.Close()
+	select {
+	case <-sess.Done():
+	default:
+		t.Errorf("expected session to be done after Close")
+	}
+
+	if _, err := sess.Open(); err == nil {
+		t.Errorf("expected error opening connection on closed session")
+	}
+}
+
+// recvUntil receives payloads from x until one matches ok, and returns it.
+func recvUntil(t *testing.T, x pb.ProxyService_MultiplexConnectionClient, ok func(*pb.Payload) bool) *pb.Payload {
+	t.Helper()
+
+	for {
+		p, err := x.Recv()
+		if err != nil {
+			t.Fatalf("Recv() = %v", err)
+		}
+		if ok(p) {
+			return p
+		}
+	}
+}
+
+func isReset(id uint32) func(*pb.Payload) bool {
+	return func(p *pb.Payload) bool {
+		_, ok := p.GetPayload().(*pb.Payload_ResetStream)
+		return ok && p.GetStreamId() == id
+	}
+}
+
+func TestSessionReset(t *testing.T) {
+	ps := protoproxy.NewServer()
+	go func() {
+		for {
+			c, err := ps.Accept()
+			if err != nil {
+				return
+			}
+			c.Close()
+		}
+	}()
+
+	s := grpc.NewServer()
+	pb.RegisterProxyServiceServer(s, ps)
+	ts := NewTestServer(s)
+	defer ts.Stop(t)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	x, err := pb.NewProxyServiceClient(ts.ClientConn(t)).MultiplexConnection(ctx)
+	if err != nil {
+		t.Fatalf("MultiplexConnection() = %v", err)
+	}
+
+	// The server closes the connection before this side has finished, so
+	// the connection is reset rather than waiting for the end of the stream.
+	x.Send(&pb.Payload{StreamId: 1, Payload: &pb.Payload_Open{Open: &pb.Open{}}})
+	x.Send(&pb.Payload{StreamId: 1, Payload: &pb.Payload_Data{Data: []byte("hello")}})
+	recvUntil(t, x, isReset(1))
+}
+
+func TestSessionWindow(t *testing.T) {
+	// Nothing accepts connections, which must not hold up the session.
+	ps := protoproxy.NewServer()
+
+	s := grpc.NewServer()
+	pb.RegisterProxyServiceServer(s, ps)
+	ts := NewTestServer(s)
+	defer ts.Stop(t)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	x, err := pb.NewProxyServiceClient(ts.ClientConn(t)).MultiplexConnection(ctx)
+	if err != nil {
+		t.Fatalf("MultiplexConnection() = %v", err)
+	}
+
+	x.Send(&pb.Payload{StreamId: 1, Payload: &pb.Payload_Open{Open: &pb.Open{}}})
+	x.Send(&pb.Payload{StreamId: 2, Payload: &pb.Payload_Open{Open: &pb.Open{}}})
+
+	// Send more than the initial window (and what's buffered by the
+	// connection) without waiting for the window to open.
+	frame := make([]byte, 32*1024)
+	for i := 0; i < 32; i++ {
+		x.Send(&pb.Payload{StreamId: 2, Payload: &pb.Payload_Data{Data: frame}})
+	}
+	recvUntil(t, x, isReset(2))
+}
@@ -6,6 +6,7 @@ import (
 
 	"google.golang.org/grpc"
 
+	"github.com/dhowden/mindmeld/internal/metrics"
 	"github.com/dhowden/mindmeld/pb"
 )
 
@@ -22,8 +23,11 @@ func RegisterServer(s *grpc.Server, x pb.ProxyServiceServer) {
 
 // NewServer creates a new Server.
 func NewServer() *Server {
+	r := metrics.NewRegistry()
 	return &Server{
-		ch: make(chan accept),
+		ch:       make(chan accept),
+		registry: r,
+		metrics:  newProxyMetrics(r),
 	}
 }
 
@@ -32,6 +36,9 @@ func NewServer() *Server {
 type Server struct {
 	ch chan accept
 
+	registry *metrics.Registry
+	metrics  *proxyMetrics
+
 	*pb.UnimplementedProxyServiceServer
 }
 
@@ -41,6 +48,11 @@ func (s *Server) Accept() (net.Conn, error) {
 	return x.c, x.err
 }
 
+// Metrics returns the metrics of the connections accepted by the Server.
+func (s *Server) Metrics() *metrics.Registry {
+	return s.registry
+}
+
 // Addr returns nil.
 func (s *Server) Addr() net.Addr { return nil }
 
@@ -49,15 +61,35 @@ func (s *Server) Close() error { return nil }
 
 // ProxyConnection implements ProxyServiceServer.
 func (s *Server) ProxyConnection(x pb.ProxyService_ProxyConnectionServer) error {
+	active := s.metrics.streams.With("stream")
+	active.Inc()
+	defer active.Dec()
+
 	// The Conn will call CloseSend when it's done writing, but the server stream
 	// doesn't have this method (you need to return).  So we wrap the stream and
 	// add a method that will emulate this behaviour on the server side.
 	ss := newServerStream(x)
-	s.ch <- accept{c: newConn(ss)}
+	s.metrics.conns.With().Inc()
+	s.ch <- accept{c: newConn(ss, s.metrics)}
 	<-ss.closed()
 	return nil
 }
 
+// MultiplexConnection implements ProxyServiceServer.  Each connection opened
+// on the stream is returned by Accept.
+func (s *Server) MultiplexConnection(x pb.ProxyService_MultiplexConnectionServer) error {
+	active := s.metrics.streams.With("multiplex")
+	active.Inc()
+	defer active.Dec()
+
+	sess := newSession(x, func(c *Conn) {
+		s.metrics.conns.With().Inc()
+		s.ch <- accept{c: c}
+	}, s.metrics)
+	<-sess.Done()
+	return nil
+}
+
 func newServerStream(x pb.ProxyService_ProxyConnectionServer) *serverStream {
 	return &serverStream{
 		ProxyService_ProxyConnectionServer: x,
@@ -0,0 +1,97 @@
+package internal
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+)
+
+// proxyV2Signature is the signature which starts a PROXY protocol v2 header.
+var proxyV2Signature = []byte("\r\n\r\n\x00\r\nQUIT\n")
+
+// WriteProxyHeader writes a HAProxy PROXY protocol header (version 1 or 2)
+// to w for a connection from src to dst.  If either address is not a TCP
+// address then the header does not include any addresses (UNKNOWN for
+// version 1, LOCAL for version 2).
+//
+// See https://www.haproxy.org/download/2.4/doc/proxy-protocol.txt.
+func WriteProxyHeader(w io.Writer, version int, src, dst net.Addr) error {
+	var b []byte
+	switch version {
+	case 1:
+		b = proxyHeaderV1(src, dst)
+	case 2:
+		b = proxyHeaderV2(src, dst)
+	default:
+		return fmt.Errorf("unsupported PROXY protocol version %d", version)
+	}
+
+	if _, err := w.Write(b); err != nil {
+		return fmt.Errorf("could not write PROXY protocol header: %w", err)
+	}
+	return nil
+}
+
+// proxyAddrs returns the TCP addresses of src and dst, converting them to
+// the same family.  Returns false if either is not a TCP address.
+func proxyAddrs(src, dst net.Addr) (s, d *net.TCPAddr, ipv4 bool, ok bool) {
+	s, ok1 := src.(*net.TCPAddr)
+	d, ok2 := dst.(*net.TCPAddr)
+	if !ok1 || !ok2 || s == nil || d == nil {
+		return nil, nil, false, false
+	}
+	ipv4 = s.IP.To4() != nil && d.IP.To4() != nil
+	return s, d, ipv4, true
+}
+
+func proxyHeaderV1(src, dst net.Addr) []byte {
+	s, d, ipv4, ok := proxyAddrs(src, dst)
+	if !ok {
+		return []byte("PROXY UNKNOWN\r\n")
+	}
+
+	if ipv4 {
+		return []byte(fmt.Sprintf("PROXY TCP4 %v %v %d %d\r\n", s.IP.To4(), d.IP.To4(), s.Port, d.Port))
+	}
+	return []byte(fmt.Sprintf("PROXY TCP6 %v %v %d %d\r\n", formatIPv6(s.IP), formatIPv6(d.IP), s.Port, d.Port))
+}
+
+// formatIPv6 formats ip as an IPv6 address.  IPv4 addresses are formatted as
+// IPv4-mapped IPv6 addresses (net.IP.String would use dotted decimal).
+func formatIPv6(ip net.IP) string {
+	if ip4 := ip.To4(); ip4 != nil {
+		return "::ffff:" + ip4.String()
+	}
+	return ip.String()
+}
+
+func proxyHeaderV2(src, dst net.Addr) []byte {
+	buf := &bytes.Buffer{}
+	buf.Write(proxyV2Signature)
+
+	s, d, ipv4, ok := proxyAddrs(src, dst)
+	if !ok {
+		buf.WriteByte(0x20) // version 2, LOCAL
+		buf.WriteByte(0x00) // UNSPEC
+		binary.Write(buf, binary.BigEndian, uint16(0))
+		return buf.Bytes()
+	}
+
+	buf.WriteByte(0x21) // version 2, PROXY
+	if ipv4 {
+		buf.WriteByte(0x11) // TCP over IPv4
+		binary.Write(buf, binary.BigEndian, uint16(12))
+		buf.Write(s.IP.To4())
+		buf.Write(d.IP.To4())
+	} else {
+		buf.WriteByte(0x21) // TCP over IPv6
+		binary.Write(buf, binary.BigEndian, uint16(36))
+		buf.Write(s.IP.To16())
+		buf.Write(d.IP.To16())
+	}
+	binary.Write(buf, binary.BigEndian, uint16(s.Port))
+	binary.Write(buf, binary.BigEndian, uint16(d.Port))
+	return buf.Bytes()
+}
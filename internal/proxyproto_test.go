@@ -0,0 +1,56 @@
+package internal_test
+
+import (
+	"bytes"
+	"net"
+	"testing"
+
+	"github.com/dhowden/mindmeld/internal"
+)
+
+func TestWriteProxyHeader(t *testing.T) {
+	src4 := &net.TCPAddr{IP: net.ParseIP("192.0.2.1"), Port: 56324}
+	dst4 := &net.TCPAddr{IP: net.ParseIP("127.0.0.1"), Port: 443}
+	src6 := &net.TCPAddr{IP: net.ParseIP("2001:db8::1"), Port: 56324}
+
+	sig := "\r\n\r\n\x00\r\nQUIT\n"
+
+	tests := []struct {
+		name     string
+		version  int
+		src, dst net.Addr
+		want     string
+	}{
+		{"v1 ipv4", 1, src4, dst4, "PROXY TCP4 192.0.2.1 127.0.0.1 56324 443\r\n"},
+		{"v1 ipv6", 1, src6, dst4, "PROXY TCP6 2001:db8::1 ::ffff:127.0.0.1 56324 443\r\n"},
+		{"v1 unknown", 1, nil, dst4, "PROXY UNKNOWN\r\n"},
+		{
+			"v2 ipv4", 2, src4, dst4,
+			sig + "\x21\x11\x00\x0c" + "\xc0\x00\x02\x01" + "\x7f\x00\x00\x01" + "\xdc\x04" + "\x01\xbb",
+		},
+		{
+			"v2 ipv6", 2, src6, dst4,
+			sig + "\x21\x21\x00\x24" +
+				"\x20\x01\x0d\xb8\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x01" +
+				"\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\xff\xff\x7f\x00\x00\x01" +
+				"\xdc\x04" + "\x01\xbb",
+		},
+		{"v2 unknown", 2, src4, nil, sig + "\x20\x00\x00\x00"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf := &bytes.Buffer{}
+			if err := internal.WriteProxyHeader(buf, tt.version, tt.src, tt.dst); err != nil {
+				t.Fatalf("WriteProxyHeader() = %v", err)
+			}
+			if got := buf.String(); got != tt.want {
+				t.Errorf("WriteProxyHeader() wrote %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	if err := internal.WriteProxyHeader(&bytes.Buffer{}, 3, src4, dst4); err == nil {
+		t.Errorf("WriteProxyHeader() = nil error for version 3")
+	}
+}
@@ -0,0 +1,90 @@
+package mindmeld_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/dhowden/mindmeld"
+	"github.com/dhowden/mindmeld/pb"
+)
+
+func TestLeaseGrace(t *testing.T) {
+	r := newAuthRouter(t, map[string]*mindmeld.Identity{
+		"alice": {Name: "alice"},
+		"bob":   {Name: "bob"},
+	}, mindmeld.WithLeaseGrace(5*time.Second))
+	alice, bob := tokenConn(t, r, "alice"), tokenConn(t, r, "bob")
+
+	l, err := mindmeld.Listen(context.Background(), alice, "svc")
+	if err != nil {
+		t.Fatalf("Listen() = %v", err)
+	}
+	waitForInstances(t, alice, "svc", 1)
+	l.Close()
+	waitForInstances(t, alice, "svc", 0)
+
+	// The name is reserved for the owner.
+	csc, err := pb.NewControlServiceClient(bob).CreateService(context.Background(), &pb.CreateServiceRequest{Name: "svc"})
+	if err != nil {
+		t.Fatalf("CreateService() = %v", err)
+	}
+	if _, err := csc.Recv(); status.Code(err) != codes.AlreadyExists {
+		t.Errorf("CreateService() by another owner = %v, expected code %v", err, codes.AlreadyExists)
+	}
+
+	// Forwards are queued until the owner reconnects.
+	got := make(chan string, 1)
+	go func() { got <- dialRead(t, bob, "svc") }()
+
+	time.Sleep(50 * time.Millisecond)
+	l, err = mindmeld.Listen(context.Background(), alice, "svc")
+	if err != nil {
+		t.Fatalf("Listen() = %v", err)
+	}
+	defer l.Close()
+	go serveName(l, "svc")
+
+	select {
+	case s := <-got:
+		if s != "svc" {
+			t.Errorf("read %q, expected %q", s, "svc")
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("queued forward was not delivered")
+	}
+}
+
+func TestLeaseExpiry(t *testing.T) {
+	r := NewTestRouter(t, mindmeld.WithLeaseGrace(200*time.Millisecond))
+	cc := r.ClientConn(t)
+
+	l, err := mindmeld.Listen(context.Background(), cc, "svc")
+	if err != nil {
+		t.Fatalf("Listen() = %v", err)
+	}
+	waitForInstances(t, cc, "svc", 1)
+
+	// Forwards waiting when the lease expires are closed.
+	l.Close()
+	waitForInstances(t, cc, "svc", 0)
+	got := make(chan string, 1)
+	go func() { got <- dialRead(t, cc, "svc") }()
+
+	select {
+	case s := <-got:
+		if s != "" {
+			t.Errorf("read %q, expected nothing", s)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("queued forward was not closed when the lease expired")
+	}
+
+	_, err = mindmeld.NewDialer(cc).DialContext(context.Background(), "tcp", "svc")
+	if got := status.Code(err); got != codes.NotFound {
+		t.Errorf("DialContext() = %v, expected code %v", err, codes.NotFound)
+	}
+}
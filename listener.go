@@ -0,0 +1,153 @@
+package mindmeld
+
+import (
+	"context"
+	"crypto/ed25519"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"sync"
+
+	"google.golang.org/grpc"
+
+	"github.com/dhowden/mindmeld/pb"
+)
+
+var _ net.Listener = (*Listener)(nil)
+
+// Listen registers the service name and returns a net.Listener which accepts
+// the connections forwarded to it.  The listener can be passed directly to
+// http.Serve, grpc.Server.Serve etc.
+//
+// Listen returns an error if the router doesn't create the service (i.e. the
+// name is owned by someone else).  The service is removed when ctx is done or
+// the listener is closed.
+func Listen(ctx context.Context, cc *grpc.ClientConn, name string, opts ...Option) (*Listener, error) {
+	o := newOptions(opts)
+
+	ctx, cancel := context.WithCancel(ctx)
+	csc, err := pb.NewControlServiceClient(cc).CreateService(ctx, &pb.CreateServiceRequest{
+		Name:          name,
+		Allow:         o.allow,
+		LoadBalancing: o.balancing,
+		PublicKey:     o.publicKey(),
+	})
+	if err != nil {
+		cancel()
+		return nil, fmt.Errorf("could not create service: %w", err)
+	}
+
+	// The router sends headers once the service has been created.
+	md, err := csc.Header()
+	if err == nil && len(md) == 0 {
+		// Stream ended without headers (a trailers-only response has
+		// empty headers), the error is returned by Recv.
+		_, err = csc.Recv()
+	}
+	if err != nil {
+		cancel()
+		return nil, fmt.Errorf("could not create service: %w", err)
+	}
+
+	l := &Listener{
+		pd:     newProxyDialer(cc, o.multiplex),
+		e2eKey: o.e2eKey,
+		name:   name,
+		cancel: cancel,
+		conns:  make(chan net.Conn),
+		done:   make(chan struct{}),
+	}
+	go l.loop(csc)
+	return l, nil
+}
+
+// Listener accepts connections forwarded to a service, implements net.Listener.
+type Listener struct {
+	pd     *proxyDialer
+	name   string
+	e2eKey ed25519.PrivateKey
+
+	cancel context.CancelFunc
+	conns  chan net.Conn
+
+	mu       sync.Mutex // protects err
+	err      error
+	doneOnce sync.Once
+	done     chan struct{}
+}
+
+func (l *Listener) loop(csc pb.ControlService_CreateServiceClient) {
+	for {
+		resp, err := csc.Recv()
+		if err != nil {
+			if err == io.EOF {
+				err = fmt.Errorf("service %q closed by router", l.name)
+			}
+			l.close(fmt.Errorf("could not receive: %w", err))
+			return
+		}
+		go l.handleConn(resp)
+	}
+}
+
+func (l *Listener) handleConn(resp *pb.CreateServiceResponse) {
+	pc, err := l.pd.dial(resp.GetToken())
+	if err != nil {
+		log.Printf("Could not create proxy connection for forward %q: %v", resp.GetToken(), err)
+		return
+	}
+	pc.SetAddr(l.Addr(), newAddr(l.name, resp.GetPeer()))
+
+	var c net.Conn = pc
+	if l.e2eKey != nil {
+		c, err = e2eServer(pc, l.e2eKey, resp.GetPeer().GetPublicKey())
+		if err != nil {
+			log.Printf("Could not secure connection for forward %q: %v", resp.GetToken(), err)
+			pc.Close()
+			return
+		}
+	}
+
+	select {
+	case l.conns <- c:
+	case <-l.done:
+		c.Close()
+	}
+}
+
+func (l *Listener) close(err error) {
+	l.doneOnce.Do(func() {
+		l.mu.Lock()
+		l.err = err
+		l.mu.Unlock()
+
+		l.cancel()
+		close(l.done)
+	})
+}
+
+// Accept waits for and returns the next connection forwarded to the service.
+func (l *Listener) Accept() (net.Conn, error) {
+	select {
+	case c := <-l.conns:
+		return c, nil
+	case <-l.done:
+	}
+
+	defer l.mu.Unlock()
+	l.mu.Lock()
+	return nil, l.err
+}
+
+// Close removes the service.  Connections which have already been accepted
+// are not closed (unless using multiplexing, when they share the listener's
+// stream).
+func (l *Listener) Close() error {
+	l.close(net.ErrClosed)
+	l.pd.close()
+	return nil
+}
+
+// Addr returns the address of the service.
+func (l *Listener) Addr() net.Addr { return &Addr{Service: l.name} }
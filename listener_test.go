@@ -0,0 +1,138 @@
+package mindmeld_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"testing"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/dhowden/mindmeld"
+)
+
+func TestListen(t *testing.T) {
+	const msg = "hello world!"
+
+	r := NewTestRouter(t)
+	cc := r.ClientConn(t)
+
+	l, err := mindmeld.Listen(context.Background(), cc, "http")
+	if err != nil {
+		t.Fatalf("Listen() = %v", err)
+	}
+	defer l.Close()
+
+	go http.Serve(l, http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		io.WriteString(w, msg)
+	}))
+
+	waitForService(t, cc, "http")
+
+	tr := &http.Transport{
+		DialContext: mindmeld.NewDialer(cc).DialContext,
+	}
+	defer tr.CloseIdleConnections()
+
+	resp, err := (&http.Client{Transport: tr}).Get("http://http/")
+	if err != nil {
+		t.Fatalf("Get() = %v", err)
+	}
+	defer resp.Body.Close()
+
+	got, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("could not read response body: %v", err)
+	}
+	if string(got) != msg {
+		t.Errorf("got %q, want %q", got, msg)
+	}
+}
+
+func TestListenClose(t *testing.T) {
+	r := NewTestRouter(t)
+	cc := r.ClientConn(t)
+
+	l, err := mindmeld.Listen(context.Background(), cc, "svc")
+	if err != nil {
+		t.Fatalf("Listen() = %v", err)
+	}
+	l.Close()
+
+	if _, err := l.Accept(); err != net.ErrClosed {
+		t.Errorf("Accept() = %v, want %v", err, net.ErrClosed)
+	}
+}
+
+func TestListenAddr(t *testing.T) {
+	r := newAuthRouter(t, map[string]*mindmeld.Identity{
+		"alice": {Name: "alice"},
+		"bob":   {Name: "bob"},
+	})
+
+	cc := tokenConn(t, r, "alice")
+	l, err := mindmeld.Listen(context.Background(), cc, "svc")
+	if err != nil {
+		t.Fatalf("Listen() = %v", err)
+	}
+	defer l.Close()
+	waitForService(t, cc, "svc")
+
+	accepted := make(chan net.Conn, 1)
+	go func() {
+		c, err := l.Accept()
+		if err != nil {
+			t.Errorf("Accept() = %v", err)
+			return
+		}
+		accepted <- c
+	}()
+
+	c, err := mindmeld.NewDialer(tokenConn(t, r, "bob")).DialContext(context.Background(), "tcp", "svc")
+	if err != nil {
+		t.Fatalf("DialContext() = %v", err)
+	}
+	defer c.Close()
+
+	if got := c.RemoteAddr().(*mindmeld.Addr); got.Service != "svc" {
+		t.Errorf("forward RemoteAddr() = %#v, want service %q", got, "svc")
+	}
+	local := c.LocalAddr().(*mindmeld.Addr)
+	if local.Identity != "bob" || local.Addr == "" {
+		t.Errorf("forward LocalAddr() = %#v, want identity %q and non-empty address", local, "bob")
+	}
+
+	sc := <-accepted
+	defer sc.Close()
+
+	if got := sc.LocalAddr().(*mindmeld.Addr); got.Service != "svc" {
+		t.Errorf("service LocalAddr() = %#v, want service %q", got, "svc")
+	}
+	remote := sc.RemoteAddr().(*mindmeld.Addr)
+	if *remote != *local {
+		t.Errorf("service RemoteAddr() = %#v, want %#v", remote, local)
+	}
+}
+
+func TestListenAlreadyExists(t *testing.T) {
+	r := newAuthRouter(t, map[string]*mindmeld.Identity{
+		"alice": {Name: "alice"},
+		"bob":   {Name: "bob"},
+	})
+
+	l, err := mindmeld.Listen(context.Background(), tokenConn(t, r, "alice"), "svc")
+	if err != nil {
+		t.Fatalf("Listen() = %v", err)
+	}
+	defer l.Close()
+
+	_, err = mindmeld.Listen(context.Background(), tokenConn(t, r, "bob"), "svc")
+	var se interface{ GRPCStatus() *status.Status }
+	if !errors.As(err, &se) || se.GRPCStatus().Code() != codes.AlreadyExists {
+		t.Errorf("Listen() by another owner = %v, expected code %v", err, codes.AlreadyExists)
+	}
+}
@@ -0,0 +1,82 @@
+package mindmeld
+
+import (
+	"net"
+	"net/http"
+	"sync/atomic"
+
+	"github.com/dhowden/mindmeld/internal/metrics"
+)
+
+// serverMetrics are the metrics of a Server, served by its MetricsHandler.
+type serverMetrics struct {
+	registry *metrics.Registry
+
+	services      *metrics.Vec
+	forwards      *metrics.Vec
+	forwardsTotal *metrics.Vec
+	bytes         *metrics.Vec
+	tokens        *metrics.Vec
+	createService *metrics.Histogram
+}
+
+func newServerMetrics() *serverMetrics {
+	r := metrics.NewRegistry()
+	return &serverMetrics{
+		registry: r,
+		services: metrics.NewGauge(r, "mindmeld_services",
+			"Number of services."),
+		forwards: metrics.NewGauge(r, "mindmeld_forwards_active",
+			"Number of active forwards, by service.", "service"),
+		forwardsTotal: metrics.NewCounter(r, "mindmeld_forwards_total",
+			"Number of forwards, by service.", "service"),
+		bytes: metrics.NewCounter(r, "mindmeld_service_bytes_total",
+			"Bytes forwarded to (up) and from (down) services, by service.", "service", "direction"),
+		tokens: metrics.NewCounter(r, "mindmeld_tokens_total",
+			"Tokens, by event (issued, claimed, expired or invalid).", "event"),
+		createService: metrics.NewHistogram(r, "mindmeld_create_service_duration_seconds",
+			"Duration of CreateService streams, by gRPC status code.", metrics.DurationBuckets, "code"),
+	}
+}
+
+// deleteService removes the series of the service name, so that deleted
+// services don't accumulate.
+func (m *serverMetrics) deleteService(name string) {
+	m.forwards.Delete(name)
+	m.forwardsTotal.Delete(name)
+	m.bytes.Delete(name, "up")
+	m.bytes.Delete(name, "down")
+}
+
+// MetricsHandler returns an http.Handler which serves the metrics of the
+// Server (and the proxy connections it accepts, see ProxyListen) on /metrics,
+// in the Prometheus text format.
+func (s *Server) MetricsHandler() http.Handler {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", s.metrics.registry)
+	return mux
+}
+
+// countingConn counts the bytes read from a net.Conn in n (accessed
+// atomically) and total.
+type countingConn struct {
+	net.Conn
+	n     *int64
+	total *metrics.Value
+}
+
+func (c *countingConn) Read(b []byte) (int, error) {
+	n, err := c.Conn.Read(b)
+	atomic.AddInt64(c.n, int64(n))
+	c.total.Add(float64(n))
+	return n, err
+}
+
+// CloseWrite closes the writing side of the underlying connection (if
+// supported).
+func (c *countingConn) CloseWrite() error {
+	if cw, ok := c.Conn.(closeWriter); ok {
+		return cw.CloseWrite()
+	}
+	return nil
+}
@@ -0,0 +1,70 @@
+package mindmeld_test
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dhowden/mindmeld"
+)
+
+// getMetrics returns the metrics served by r.
+func getMetrics(r *TestRouter) string {
+	w := httptest.NewRecorder()
+	r.MetricsHandler().ServeHTTP(w, httptest.NewRequest("GET", "/metrics", nil))
+	b, _ := ioutil.ReadAll(w.Body)
+	return string(b)
+}
+
+func TestMetricsHandler(t *testing.T) {
+	r := NewTestRouter(t)
+	cc := r.ClientConn(t)
+
+	l, err := mindmeld.Listen(context.Background(), cc, "svc")
+	if err != nil {
+		t.Fatalf("Listen() = %v", err)
+	}
+	go serveName(l, "svc")
+	waitForService(t, cc, "svc")
+
+	for i := 0; i < 2; i++ {
+		if got := dialRead(t, cc, "svc"); got != "svc" {
+			t.Fatalf("dialRead() = %q, want %q", got, "svc")
+		}
+	}
+
+	got := getMetrics(r)
+	for _, want := range []string{
+		"mindmeld_services 1",
+		`mindmeld_forwards_total{service="svc"} 2`,
+		`mindmeld_service_bytes_total{service="svc",direction="down"} 6`,
+		`mindmeld_service_bytes_total{service="svc",direction="up"} 0`,
+		`mindmeld_tokens_total{event="issued"} 4`,
+		"# TYPE mindmeld_forwards_active gauge",
+		"# TYPE mindmeld_create_service_duration_seconds histogram",
+		"# TYPE mindmeld_proxy_streams_active gauge",
+		"# TYPE mindmeld_proxy_bytes_total counter",
+	} {
+		if !strings.Contains(got, want+"\n") {
+			t.Errorf("metrics missing %q, got:\n%s", want, got)
+		}
+	}
+
+	// Each Server has its own metrics.
+	if got := getMetrics(NewTestRouter(t)); strings.Contains(got, `service="svc"`) {
+		t.Errorf("metrics of new router include svc, got:\n%s", got)
+	}
+
+	// The series of deleted services are removed.
+	l.Close()
+	deadline := time.Now().Add(time.Second)
+	for !strings.Contains(getMetrics(r), "mindmeld_services 0\n") && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if got := getMetrics(r); strings.Contains(got, `service="svc"`) {
+		t.Errorf("metrics include deleted service, got:\n%s", got)
+	}
+}
@@ -0,0 +1,115 @@
+package mindmeld
+
+import (
+	"crypto/ed25519"
+	"time"
+
+	"github.com/dhowden/mindmeld/pb"
+)
+
+// Option configures clients (ServiceClient, ForwardClient, Dialer and
+// Listen).  Options which don't apply to a client are ignored.
+type Option func(*options)
+
+type options struct {
+	allow     *pb.AccessList
+	balancing pb.LoadBalancing
+	multiplex bool
+
+	proxyProtocol int
+	idleTimeout   time.Duration
+
+	reconnect *Backoff
+	stateFunc func(State, error)
+
+	e2eKey     ed25519.PrivateKey
+	serviceKey ed25519.PublicKey
+}
+
+func newOptions(opts []Option) *options {
+	o := &options{
+		idleTimeout: DefaultIdleTimeout,
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// WithAccessList sets the callers allowed to forward to a service (see
+// ParseAccessList).  The owner of the service is always allowed.  By default
+// all callers are allowed.
+//
+// Applies to ServiceClient and Listen.
+func WithAccessList(allow *pb.AccessList) Option {
+	return func(o *options) {
+		o.allow = allow
+	}
+}
+
+// WithLoadBalancing sets the load balancing policy used by the router to pick
+// between instances of the service.  Defaults to pb.LoadBalancing_ROUND_ROBIN.
+//
+// Applies to ServiceClient and Listen.
+func WithLoadBalancing(policy pb.LoadBalancing) Option {
+	return func(o *options) {
+		o.balancing = policy
+	}
+}
+
+// WithMultiplexing carries all proxy connections over a single long-lived
+// stream to the router, rather than one stream per connection.  This reduces
+// the latency of setting up each connection.
+//
+// Applies to all clients.
+func WithMultiplexing() Option {
+	return func(o *options) {
+		o.multiplex = true
+	}
+}
+
+// WithProxyProtocol writes a HAProxy PROXY protocol header (version 1 or 2)
+// on each connection to the service target, containing the address of the
+// forwarder as seen by the router.  Use when the target (i.e. nginx or
+// HAProxy) is configured to accept it.
+//
+// Applies to ServiceClient.
+func WithProxyProtocol(version int) Option {
+	return func(o *options) {
+		o.proxyProtocol = version
+	}
+}
+
+// WithIdleTimeout sets the time after which UDP sessions with no traffic in
+// either direction are closed.  Defaults to DefaultIdleTimeout.
+//
+// Applies to ServiceClient and ForwardClient.
+func WithIdleTimeout(d time.Duration) Option {
+	return func(o *options) {
+		o.idleTimeout = d
+	}
+}
+
+// WithReconnect re-registers the service using backoff when its registration
+// with the router is lost (i.e. the router restarts), rather than returning
+// from Register.  Connections which have already been forwarded are not
+// affected.  Registration is not retried for errors which can't be fixed by
+// retrying (invalid requests and credentials).
+//
+// Applies to ServiceClient.
+func WithReconnect(backoff Backoff) Option {
+	return func(o *options) {
+		o.reconnect = &backoff
+	}
+}
+
+// WithStateFunc sets a function which is called whenever the state of the
+// service's registration changes, with the error which caused the change
+// (if any).  The function is called from Register, so must not block.
+//
+// Applies to ServiceClient.
+func WithStateFunc(f func(State, error)) Option {
+	return func(o *options) {
+		o.stateFunc = f
+	}
+}
@@ -26,6 +26,8 @@ type ControlServiceClient interface {
 	ForwardToService(ctx context.Context, in *ForwardToServiceRequest, opts ...grpc.CallOption) (*ForwardToServiceResponse, error)
 	// List services.
 	ListServices(ctx context.Context, in *ListServicesRequest, opts ...grpc.CallOption) (*ListServicesResponse, error)
+	// List connections (forwards) to services hosted by the router.
+	ListConnections(ctx context.Context, in *ListConnectionsRequest, opts ...grpc.CallOption) (*ListConnectionsResponse, error)
 }
 
 type controlServiceClient struct {
@@ -86,6 +88,15 @@ func (c *controlServiceClient) ListServices(ctx context.Context, in *ListService
 	return out, nil
 }
 
+func (c *controlServiceClient) ListConnections(ctx context.Context, in *ListConnectionsRequest, opts ...grpc.CallOption) (*ListConnectionsResponse, error) {
+	out := new(ListConnectionsResponse)
+	err := c.cc.Invoke(ctx, "/mindmeld.ControlService/ListConnections", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // ControlServiceServer is the server API for ControlService service.
 // All implementations must embed UnimplementedControlServiceServer
 // for forward compatibility
@@ -98,6 +109,8 @@ type ControlServiceServer interface {
 	ForwardToService(context.Context, *ForwardToServiceRequest) (*ForwardToServiceResponse, error)
 	// List services.
 	ListServices(context.Context, *ListServicesRequest) (*ListServicesResponse, error)
+	// List connections (forwards) to services hosted by the router.
+	ListConnections(context.Context, *ListConnectionsRequest) (*ListConnectionsResponse, error)
 	mustEmbedUnimplementedControlServiceServer()
 }
 
@@ -114,6 +127,9 @@ func (UnimplementedControlServiceServer) ForwardToService(context.Context, *Forw
 func (UnimplementedControlServiceServer) ListServices(context.Context, *ListServicesRequest) (*ListServicesResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method ListServices not implemented")
 }
+func (UnimplementedControlServiceServer) ListConnections(context.Context, *ListConnectionsRequest) (*ListConnectionsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListConnections not implemented")
+}
 func (UnimplementedControlServiceServer) mustEmbedUnimplementedControlServiceServer() {}
 
 // UnsafeControlServiceServer may be embedded to opt out of forward compatibility for this service.
@@ -184,6 +200,24 @@ func _ControlService_ListServices_Handler(srv interface{}, ctx context.Context,
 	return interceptor(ctx, in, info, handler)
 }
 
+func _ControlService_ListConnections_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListConnectionsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ControlServiceServer).ListConnections(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/mindmeld.ControlService/ListConnections",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ControlServiceServer).ListConnections(ctx, req.(*ListConnectionsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // ControlService_ServiceDesc is the grpc.ServiceDesc for ControlService service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -199,6 +233,10 @@ var ControlService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "ListServices",
 			Handler:    _ControlService_ListServices_Handler,
 		},
+		{
+			MethodName: "ListConnections",
+			Handler:    _ControlService_ListConnections_Handler,
+		},
 	},
 	Streams: []grpc.StreamDesc{
 		{
@@ -210,11 +248,183 @@ var ControlService_ServiceDesc = grpc.ServiceDesc{
 	Metadata: "mindmeld.proto",
 }
 
+// AdminServiceClient is the client API for AdminService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type AdminServiceClient interface {
+	// Delete a service, closing its connections and ending its CreateService
+	// streams.
+	DeleteService(ctx context.Context, in *DeleteServiceRequest, opts ...grpc.CallOption) (*DeleteServiceResponse, error)
+	// Close a connection (see ListConnections).
+	CloseConnection(ctx context.Context, in *CloseConnectionRequest, opts ...grpc.CallOption) (*CloseConnectionResponse, error)
+	// Drain a service: stop accepting new forwards, and delete the service
+	// when its existing connections have finished.
+	DrainService(ctx context.Context, in *DrainServiceRequest, opts ...grpc.CallOption) (*DrainServiceResponse, error)
+}
+
+type adminServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewAdminServiceClient(cc grpc.ClientConnInterface) AdminServiceClient {
+	return &adminServiceClient{cc}
+}
+
+func (c *adminServiceClient) DeleteService(ctx context.Context, in *DeleteServiceRequest, opts ...grpc.CallOption) (*DeleteServiceResponse, error) {
+	out := new(DeleteServiceResponse)
+	err := c.cc.Invoke(ctx, "/mindmeld.AdminService/DeleteService", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adminServiceClient) CloseConnection(ctx context.Context, in *CloseConnectionRequest, opts ...grpc.CallOption) (*CloseConnectionResponse, error) {
+	out := new(CloseConnectionResponse)
+	err := c.cc.Invoke(ctx, "/mindmeld.AdminService/CloseConnection", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adminServiceClient) DrainService(ctx context.Context, in *DrainServiceRequest, opts ...grpc.CallOption) (*DrainServiceResponse, error) {
+	out := new(DrainServiceResponse)
+	err := c.cc.Invoke(ctx, "/mindmeld.AdminService/DrainService", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// AdminServiceServer is the server API for AdminService service.
+// All implementations must embed UnimplementedAdminServiceServer
+// for forward compatibility
+type AdminServiceServer interface {
+	// Delete a service, closing its connections and ending its CreateService
+	// streams.
+	DeleteService(context.Context, *DeleteServiceRequest) (*DeleteServiceResponse, error)
+	// Close a connection (see ListConnections).
+	CloseConnection(context.Context, *CloseConnectionRequest) (*CloseConnectionResponse, error)
+	// Drain a service: stop accepting new forwards, and delete the service
+	// when its existing connections have finished.
+	DrainService(context.Context, *DrainServiceRequest) (*DrainServiceResponse, error)
+	mustEmbedUnimplementedAdminServiceServer()
+}
+
+// UnimplementedAdminServiceServer must be embedded to have forward compatible implementations.
+type UnimplementedAdminServiceServer struct {
+}
+
+func (UnimplementedAdminServiceServer) DeleteService(context.Context, *DeleteServiceRequest) (*DeleteServiceResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteService not implemented")
+}
+func (UnimplementedAdminServiceServer) CloseConnection(context.Context, *CloseConnectionRequest) (*CloseConnectionResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CloseConnection not implemented")
+}
+func (UnimplementedAdminServiceServer) DrainService(context.Context, *DrainServiceRequest) (*DrainServiceResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DrainService not implemented")
+}
+func (UnimplementedAdminServiceServer) mustEmbedUnimplementedAdminServiceServer() {}
+
+// UnsafeAdminServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to AdminServiceServer will
+// result in compilation errors.
+type UnsafeAdminServiceServer interface {
+	mustEmbedUnimplementedAdminServiceServer()
+}
+
+func RegisterAdminServiceServer(s grpc.ServiceRegistrar, srv AdminServiceServer) {
+	s.RegisterService(&AdminService_ServiceDesc, srv)
+}
+
+func _AdminService_DeleteService_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteServiceRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdminServiceServer).DeleteService(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/mindmeld.AdminService/DeleteService",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdminServiceServer).DeleteService(ctx, req.(*DeleteServiceRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AdminService_CloseConnection_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CloseConnectionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdminServiceServer).CloseConnection(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/mindmeld.AdminService/CloseConnection",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdminServiceServer).CloseConnection(ctx, req.(*CloseConnectionRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AdminService_DrainService_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DrainServiceRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdminServiceServer).DrainService(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/mindmeld.AdminService/DrainService",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdminServiceServer).DrainService(ctx, req.(*DrainServiceRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// AdminService_ServiceDesc is the grpc.ServiceDesc for AdminService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var AdminService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "mindmeld.AdminService",
+	HandlerType: (*AdminServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "DeleteService",
+			Handler:    _AdminService_DeleteService_Handler,
+		},
+		{
+			MethodName: "CloseConnection",
+			Handler:    _AdminService_CloseConnection_Handler,
+		},
+		{
+			MethodName: "DrainService",
+			Handler:    _AdminService_DrainService_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "mindmeld.proto",
+}
+
 // ProxyServiceClient is the client API for ProxyService service.
 //
 // For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
 type ProxyServiceClient interface {
 	ProxyConnection(ctx context.Context, opts ...grpc.CallOption) (ProxyService_ProxyConnectionClient, error)
+	// Multiplex many connections over a single stream.  The client opens
+	// connections with Open payloads, and every payload carries the stream_id
+	// of the connection it belongs to.
+	MultiplexConnection(ctx context.Context, opts ...grpc.CallOption) (ProxyService_MultiplexConnectionClient, error)
 }
 
 type proxyServiceClient struct {
@@ -256,11 +466,46 @@ func (x *proxyServiceProxyConnectionClient) Recv() (*Payload, error) {
 	return m, nil
 }
 
+func (c *proxyServiceClient) MultiplexConnection(ctx context.Context, opts ...grpc.CallOption) (ProxyService_MultiplexConnectionClient, error) {
+	stream, err := c.cc.NewStream(ctx, &ProxyService_ServiceDesc.Streams[1], "/mindmeld.ProxyService/MultiplexConnection", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &proxyServiceMultiplexConnectionClient{stream}
+	return x, nil
+}
+
+type ProxyService_MultiplexConnectionClient interface {
+	Send(*Payload) error
+	Recv() (*Payload, error)
+	grpc.ClientStream
+}
+
+type proxyServiceMultiplexConnectionClient struct {
+	grpc.ClientStream
+}
+
+func (x *proxyServiceMultiplexConnectionClient) Send(m *Payload) error {
+	return x.ClientStream.SendMsg(m)
+}
+
+func (x *proxyServiceMultiplexConnectionClient) Recv() (*Payload, error) {
+	m := new(Payload)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
 // ProxyServiceServer is the server API for ProxyService service.
 // All implementations must embed UnimplementedProxyServiceServer
 // for forward compatibility
 type ProxyServiceServer interface {
 	ProxyConnection(ProxyService_ProxyConnectionServer) error
+	// Multiplex many connections over a single stream.  The client opens
+	// connections with Open payloads, and every payload carries the stream_id
+	// of the connection it belongs to.
+	MultiplexConnection(ProxyService_MultiplexConnectionServer) error
 	mustEmbedUnimplementedProxyServiceServer()
 }
 
@@ -271,6 +516,9 @@ type UnimplementedProxyServiceServer struct {
 func (UnimplementedProxyServiceServer) ProxyConnection(ProxyService_ProxyConnectionServer) error {
 	return status.Errorf(codes.Unimplemented, "method ProxyConnection not implemented")
 }
+func (UnimplementedProxyServiceServer) MultiplexConnection(ProxyService_MultiplexConnectionServer) error {
+	return status.Errorf(codes.Unimplemented, "method MultiplexConnection not implemented")
+}
 func (UnimplementedProxyServiceServer) mustEmbedUnimplementedProxyServiceServer() {}
 
 // UnsafeProxyServiceServer may be embedded to opt out of forward compatibility for this service.
@@ -310,6 +558,32 @@ func (x *proxyServiceProxyConnectionServer) Recv() (*Payload, error) {
 	return m, nil
 }
 
+func _ProxyService_MultiplexConnection_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(ProxyServiceServer).MultiplexConnection(&proxyServiceMultiplexConnectionServer{stream})
+}
+
+type ProxyService_MultiplexConnectionServer interface {
+	Send(*Payload) error
+	Recv() (*Payload, error)
+	grpc.ServerStream
+}
+
+type proxyServiceMultiplexConnectionServer struct {
+	grpc.ServerStream
+}
+
+func (x *proxyServiceMultiplexConnectionServer) Send(m *Payload) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func (x *proxyServiceMultiplexConnectionServer) Recv() (*Payload, error) {
+	m := new(Payload)
+	if err := x.ServerStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
 // ProxyService_ServiceDesc is the grpc.ServiceDesc for ProxyService service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -324,6 +598,12 @@ var ProxyService_ServiceDesc = grpc.ServiceDesc{
 			ServerStreams: true,
 			ClientStreams: true,
 		},
+		{
+			StreamName:    "MultiplexConnection",
+			Handler:       _ProxyService_MultiplexConnection_Handler,
+			ServerStreams: true,
+			ClientStreams: true,
+		},
 	},
 	Metadata: "mindmeld.proto",
 }
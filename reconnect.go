@@ -0,0 +1,96 @@
+package mindmeld
+
+import (
+	"errors"
+	"math/rand"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// State of a ServiceClient's registration with the router.
+type State int
+
+const (
+	// StateConnecting is when the service is being registered.
+	StateConnecting State = iota
+
+	// StateRegistered is when the service is registered, and so receiving
+	// forwards.
+	StateRegistered
+
+	// StateDisconnected is when the registration has been lost, and is
+	// waiting to be retried.
+	StateDisconnected
+
+	// StateClosed is when the registration has ended, and won't be retried.
+	StateClosed
+)
+
+func (s State) String() string {
+	switch s {
+	case StateConnecting:
+		return "connecting"
+	case StateRegistered:
+		return "registered"
+	case StateDisconnected:
+		return "disconnected"
+	case StateClosed:
+		return "closed"
+	}
+	return "unknown"
+}
+
+// Backoff configures the delay between retries: exponential backoff with
+// jitter.
+type Backoff struct {
+	// Initial delay.
+	Initial time.Duration
+
+	// Max is the maximum delay.
+	Max time.Duration
+
+	// Multiplier applied to the delay after each failed attempt.
+	Multiplier float64
+
+	// Jitter is the fraction of the delay which is randomised (0-1), so that
+	// clients don't retry in lock-step.
+	Jitter float64
+}
+
+// DefaultBackoff is a Backoff suitable for reconnecting to a router.
+var DefaultBackoff = Backoff{
+	Initial:    1 * time.Second,
+	Max:        1 * time.Minute,
+	Multiplier: 2,
+	Jitter:     0.2,
+}
+
+// delay returns the delay before retry attempt (starting from 0).
+func (b Backoff) delay(attempt int) time.Duration {
+	d := float64(b.Initial)
+	for i := 0; i < attempt && d < float64(b.Max); i++ {
+		d *= b.Multiplier
+	}
+	if d > float64(b.Max) {
+		d = float64(b.Max)
+	}
+	d *= 1 - b.Jitter*rand.Float64()
+	return time.Duration(d)
+}
+
+// retryable returns true if the registration should be retried after err.
+// Errors which won't be fixed by retrying (i.e. invalid requests or
+// credentials, or the service being deleted by an admin) are not retried.
+func retryable(err error) bool {
+	var se interface{ GRPCStatus() *status.Status }
+	if !errors.As(err, &se) {
+		return true
+	}
+	switch se.GRPCStatus().Code() {
+	case codes.InvalidArgument, codes.Unauthenticated, codes.PermissionDenied, codes.Aborted:
+		return false
+	}
+	return true
+}
@@ -0,0 +1,143 @@
+package mindmeld_test
+
+import (
+	"context"
+	"net"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/backoff"
+
+	"github.com/dhowden/mindmeld"
+)
+
+// restartableRouter is a TestRouter which can be restarted, with clients
+// reconnecting to the new router.
+type restartableRouter struct {
+	mu sync.Mutex // protects r
+	r  *TestRouter
+}
+
+func (rr *restartableRouter) restart(t *testing.T) {
+	rr.mu.Lock()
+	old := rr.r
+	rr.r = NewTestRouter(t)
+	rr.mu.Unlock()
+
+	old.gs.Stop()
+	old.Server.Close()
+}
+
+func (rr *restartableRouter) clientConn(t *testing.T) *grpc.ClientConn {
+	cc, err := grpc.Dial("bufconn",
+		grpc.WithContextDialer(func(context.Context, string) (net.Conn, error) {
+			rr.mu.Lock()
+			defer rr.mu.Unlock()
+			return rr.r.l.Dial()
+		}),
+		grpc.WithInsecure(),
+		grpc.WithConnectParams(grpc.ConnectParams{
+			Backoff:           backoff.Config{BaseDelay: 10 * time.Millisecond, Multiplier: 1, MaxDelay: 10 * time.Millisecond},
+			MinConnectTimeout: time.Second,
+		}),
+	)
+	if err != nil {
+		t.Fatalf("unexpected error from grpc.Dial: %v", err)
+	}
+	t.Cleanup(func() { cc.Close() })
+	return cc
+}
+
+// waitForState waits for state to be received on states.
+func waitForState(t *testing.T, states <-chan mindmeld.State, want mindmeld.State) {
+	t.Helper()
+
+	timeout := time.After(5 * time.Second)
+	for {
+		select {
+		case s := <-states:
+			if s == want {
+				return
+			}
+		case <-timeout:
+			t.Fatalf("timed out waiting for state %v", want)
+		}
+	}
+}
+
+var testBackoff = mindmeld.Backoff{
+	Initial:    10 * time.Millisecond,
+	Max:        50 * time.Millisecond,
+	Multiplier: 2,
+	Jitter:     0.2,
+}
+
+func TestServiceClientReconnect(t *testing.T) {
+	rr := &restartableRouter{r: NewTestRouter(t)}
+	cc := rr.clientConn(t)
+
+	l, err := net.Listen("tcp", "localhost:0")
+	if err != nil {
+		t.Fatalf("could not listen: %v", err)
+	}
+	defer l.Close()
+	go countServer(l)
+
+	states := make(chan mindmeld.State, 100)
+	sc := mindmeld.NewServiceClient(cc, "count", l.Addr().String(),
+		mindmeld.WithReconnect(testBackoff),
+		mindmeld.WithStateFunc(func(s mindmeld.State, _ error) { states <- s }),
+	)
+	defer sc.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errc := make(chan error, 1)
+	go func() { errc <- sc.Register(ctx) }()
+
+	waitForState(t, states, mindmeld.StateRegistered)
+	rr.restart(t)
+	waitForState(t, states, mindmeld.StateDisconnected)
+	waitForState(t, states, mindmeld.StateRegistered)
+
+	// The service is registered with the new router.
+	waitForService(t, cc, "count")
+
+	cancel()
+	waitForState(t, states, mindmeld.StateClosed)
+	select {
+	case <-errc:
+	case <-time.After(time.Second):
+		t.Fatalf("Register() did not return after ctx was cancelled")
+	}
+}
+
+func TestServiceClientReconnectNotRetryable(t *testing.T) {
+	r := NewTestRouter(t, mindmeld.WithAuthenticator(mindmeld.NewTokenAuthenticator(nil)))
+	cc := r.ClientConn(t)
+
+	var registered int32
+	sc := mindmeld.NewServiceClient(cc, "svc", "localhost:0", mindmeld.WithReconnect(testBackoff), mindmeld.WithStateFunc(func(s mindmeld.State, _ error) {
+		if s == mindmeld.StateRegistered {
+			atomic.StoreInt32(&registered, 1)
+		}
+	}))
+	defer sc.Close()
+
+	errc := make(chan error, 1)
+	go func() { errc <- sc.Register(context.Background()) }()
+
+	select {
+	case err := <-errc:
+		if err == nil {
+			t.Errorf("Register() = nil, expected error")
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("Register() retried unauthenticated registration")
+	}
+	if atomic.LoadInt32(&registered) != 0 {
+		t.Errorf("state was %v for rejected registration", mindmeld.StateRegistered)
+	}
+}
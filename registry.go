@@ -0,0 +1,231 @@
+package mindmeld
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/dhowden/mindmeld/pb"
+)
+
+// ServiceRecord is the definition of a service, as stored in a Registry.
+type ServiceRecord struct {
+	Name          string           `json:"name"`
+	Owner         string           `json:"owner"`
+	Created       time.Time        `json:"created"`
+	Allow         *pb.AccessList   `json:"allow,omitempty"`
+	LoadBalancing pb.LoadBalancing `json:"load_balancing,omitempty"`
+	Protocol      pb.Protocol      `json:"protocol,omitempty"`
+	PublicKey     []byte           `json:"public_key,omitempty"`
+}
+
+// Registry stores service definitions, so that they (and their names) are
+// kept across restarts of the router.  Implementations must be safe for
+// concurrent use.
+type Registry interface {
+	// Put creates or replaces the record for a service.
+	Put(r *ServiceRecord) error
+
+	// Delete removes the record for the service name (if any).
+	Delete(name string) error
+
+	// List returns all records, ordered by name.
+	List() ([]*ServiceRecord, error)
+}
+
+// NewMemoryRegistry creates a Registry which is kept in memory.  This is the
+// default, so service definitions are lost when the router restarts.
+func NewMemoryRegistry() Registry {
+	return &memoryRegistry{
+		records: make(map[string]*ServiceRecord),
+	}
+}
+
+type memoryRegistry struct {
+	mu      sync.Mutex
+	records map[string]*ServiceRecord
+}
+
+func (m *memoryRegistry) Put(r *ServiceRecord) error {
+	defer m.mu.Unlock()
+	m.mu.Lock()
+
+	m.records[r.Name] = r
+	return nil
+}
+
+func (m *memoryRegistry) Delete(name string) error {
+	defer m.mu.Unlock()
+	m.mu.Lock()
+
+	delete(m.records, name)
+	return nil
+}
+
+func (m *memoryRegistry) List() ([]*ServiceRecord, error) {
+	defer m.mu.Unlock()
+	m.mu.Lock()
+
+	out := make([]*ServiceRecord, 0, len(m.records))
+	for _, r := range m.records {
+		out = append(out, r)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out, nil
+}
+
+// journalEntry is a line of the FileRegistry journal.
+type journalEntry struct {
+	Put    *ServiceRecord `json:"put,omitempty"`
+	Delete string         `json:"delete,omitempty"`
+}
+
+// OpenFileRegistry opens (or creates) the FileRegistry at path.
+func OpenFileRegistry(path string) (*FileRegistry, error) {
+	m := NewMemoryRegistry().(*memoryRegistry)
+	if err := replayJournal(path, m); err != nil {
+		return nil, err
+	}
+
+	// Compact the journal, so that it only holds the current records.
+	records, _ := m.List()
+	if err := writeJournal(path, records); err != nil {
+		return nil, err
+	}
+
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("could not open registry: %w", err)
+	}
+	return &FileRegistry{
+		m: m,
+		f: f,
+	}, nil
+}
+
+// FileRegistry is a Registry which is kept in memory and written to a
+// journal file (one JSON entry per line), so that it survives restarts.
+// The journal is compacted when it is opened.
+type FileRegistry struct {
+	m *memoryRegistry
+
+	mu sync.Mutex // protects f
+	f  *os.File
+}
+
+// Put implements Registry.
+func (r *FileRegistry) Put(rec *ServiceRecord) error {
+	if err := r.append(&journalEntry{Put: rec}); err != nil {
+		return err
+	}
+	return r.m.Put(rec)
+}
+
+// Delete implements Registry.
+func (r *FileRegistry) Delete(name string) error {
+	if err := r.append(&journalEntry{Delete: name}); err != nil {
+		return err
+	}
+	return r.m.Delete(name)
+}
+
+// List implements Registry.
+func (r *FileRegistry) List() ([]*ServiceRecord, error) {
+	return r.m.List()
+}
+
+// Close the registry.
+func (r *FileRegistry) Close() error {
+	defer r.mu.Unlock()
+	r.mu.Lock()
+
+	return r.f.Close()
+}
+
+func (r *FileRegistry) append(e *journalEntry) error {
+	b, err := json.Marshal(e)
+	if err != nil {
+		return fmt.Errorf("could not encode registry entry: %w", err)
+	}
+
+	defer r.mu.Unlock()
+	r.mu.Lock()
+
+	if _, err := r.f.Write(append(b, '\n')); err != nil {
+		return fmt.Errorf("could not write registry entry: %w", err)
+	}
+	if err := r.f.Sync(); err != nil {
+		return fmt.Errorf("could not sync registry: %w", err)
+	}
+	return nil
+}
+
+// replayJournal applies the entries of the journal at path (if it exists)
+// to m.
+func replayJournal(path string, m *memoryRegistry) error {
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("could not open registry: %w", err)
+	}
+	defer f.Close()
+
+	s := bufio.NewScanner(f)
+	s.Buffer(nil, 1024*1024)
+	for n := 1; s.Scan(); n++ {
+		e := &journalEntry{}
+		if err := json.Unmarshal(s.Bytes(), e); err != nil {
+			return fmt.Errorf("could not decode registry line %d: %w", n, err)
+		}
+		switch {
+		case e.Put != nil:
+			m.Put(e.Put)
+		case e.Delete != "":
+			m.Delete(e.Delete)
+		}
+	}
+	if err := s.Err(); err != nil {
+		return fmt.Errorf("could not read registry: %w", err)
+	}
+	return nil
+}
+
+// writeJournal atomically replaces the journal at path with puts for records.
+func writeJournal(path string, records []*ServiceRecord) error {
+	f, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp")
+	if err != nil {
+		return fmt.Errorf("could not create registry: %w", err)
+	}
+	defer os.Remove(f.Name())
+
+	w := bufio.NewWriter(f)
+	enc := json.NewEncoder(w)
+	for _, r := range records {
+		if err := enc.Encode(&journalEntry{Put: r}); err != nil {
+			f.Close()
+			return fmt.Errorf("could not write registry: %w", err)
+		}
+	}
+	if err := w.Flush(); err != nil {
+		f.Close()
+		return fmt.Errorf("could not write registry: %w", err)
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return fmt.Errorf("could not sync registry: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("could not write registry: %w", err)
+	}
+	if err := os.Rename(f.Name(), path); err != nil {
+		return fmt.Errorf("could not replace registry: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,112 @@
+package mindmeld_test
+
+import (
+	"context"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/dhowden/mindmeld"
+	"github.com/dhowden/mindmeld/pb"
+)
+
+func TestFileRegistry(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "registry.json")
+
+	r, err := mindmeld.OpenFileRegistry(path)
+	if err != nil {
+		t.Fatalf("OpenFileRegistry() = %v", err)
+	}
+
+	created := time.Date(2021, 3, 1, 12, 0, 0, 0, time.UTC)
+	records := []*mindmeld.ServiceRecord{
+		{Name: "a", Owner: "alice", Created: created, Allow: &pb.AccessList{Groups: []string{"dev"}}},
+		{Name: "b", Owner: "bob", Created: created, Protocol: pb.Protocol_UDP},
+		{Name: "c", Owner: "carol", Created: created},
+	}
+	for _, rec := range records {
+		if err := r.Put(rec); err != nil {
+			t.Fatalf("Put() = %v", err)
+		}
+	}
+	if err := r.Delete("b"); err != nil {
+		t.Fatalf("Delete() = %v", err)
+	}
+	r.Close()
+
+	// Reopen (and compact) twice to check the journal survives compaction.
+	for i := 0; i < 2; i++ {
+		r, err = mindmeld.OpenFileRegistry(path)
+		if err != nil {
+			t.Fatalf("OpenFileRegistry() = %v", err)
+		}
+		got, err := r.List()
+		if err != nil {
+			t.Fatalf("List() = %v", err)
+		}
+		r.Close()
+
+		if len(got) != 2 || got[0].Name != "a" || got[1].Name != "c" {
+			t.Fatalf("List() = %v, expected services a and c", got)
+		}
+		if !got[0].Created.Equal(created) || got[0].Owner != "alice" || !reflect.DeepEqual(got[0].Allow.GetGroups(), []string{"dev"}) {
+			t.Errorf("List()[0] = %+v, expected %+v", got[0], records[0])
+		}
+	}
+}
+
+func TestServerRegistry(t *testing.T) {
+	reg := mindmeld.NewMemoryRegistry()
+	auth := mindmeld.WithAuthenticator(mindmeld.NewTokenAuthenticator(map[string]*mindmeld.Identity{
+		"alice": {Name: "alice"},
+		"bob":   {Name: "bob"},
+	}))
+
+	r1 := NewTestRouter(t, auth, mindmeld.WithRegistry(reg), mindmeld.WithLeaseGrace(time.Minute))
+	alice := tokenConn(t, r1, "alice")
+	l, err := mindmeld.Listen(context.Background(), alice, "svc", mindmeld.WithLoadBalancing(pb.LoadBalancing_RANDOM))
+	if err != nil {
+		t.Fatalf("Listen() = %v", err)
+	}
+	defer l.Close()
+	waitForInstances(t, alice, "svc", 1)
+	r1.Close()
+
+	// The service is restored (without instances) by a new router.
+	r2 := NewTestRouter(t, auth, mindmeld.WithRegistry(reg), mindmeld.WithLeaseGrace(time.Minute))
+	resp, err := pb.NewControlServiceClient(tokenConn(t, r2, "alice")).ListServices(context.Background(), &pb.ListServicesRequest{})
+	if err != nil {
+		t.Fatalf("ListServices() = %v", err)
+	}
+	if len(resp.GetServices()) != 1 {
+		t.Fatalf("ListServices() = %v, expected 1 service", resp.GetServices())
+	}
+	svc := resp.GetServices()[0]
+	if svc.GetName() != "svc" || svc.GetOwner() != "alice" || svc.GetInstances() != 0 || svc.GetLoadBalancing() != pb.LoadBalancing_RANDOM {
+		t.Errorf("ListServices() = %v, expected restored svc", svc)
+	}
+
+	// The name is still reserved for its owner.
+	csc, err := pb.NewControlServiceClient(tokenConn(t, r2, "bob")).CreateService(context.Background(), &pb.CreateServiceRequest{Name: "svc"})
+	if err != nil {
+		t.Fatalf("CreateService() = %v", err)
+	}
+	if _, err := csc.Recv(); status.Code(err) != codes.AlreadyExists {
+		t.Errorf("CreateService() by another owner = %v, expected code %v", err, codes.AlreadyExists)
+	}
+
+	// Without a lease grace restored services are given a default lease,
+	// rather than being deleted immediately.
+	r3 := NewTestRouter(t, auth, mindmeld.WithRegistry(reg))
+	resp, err = pb.NewControlServiceClient(tokenConn(t, r3, "alice")).ListServices(context.Background(), &pb.ListServicesRequest{})
+	if err != nil {
+		t.Fatalf("ListServices() = %v", err)
+	}
+	if len(resp.GetServices()) != 1 {
+		t.Errorf("ListServices() = %v, expected 1 service", resp.GetServices())
+	}
+}
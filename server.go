@@ -1,155 +1,451 @@
 package mindmeld
 
 import (
+	"bytes"
 	"context"
-	"crypto/rand"
-	"encoding/binary"
-	"encoding/hex"
+	"crypto/ed25519"
 	"fmt"
 	"log"
 	"net"
+	"sort"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/status"
 	"google.golang.org/protobuf/types/known/timestamppb"
 
 	"github.com/dhowden/mindmeld/internal"
+	"github.com/dhowden/mindmeld/internal/metrics"
 
 	"github.com/dhowden/mindmeld/pb"
 )
 
-// NewTokenSource creates a new TokenSource.
-func NewTokenSource() *TokenSource {
-	return &TokenSource{}
+var _ pb.ControlServiceServer = (*Server)(nil)
+
+// service represents a service, and handles incoming forwards via
+// its instances.
+type service struct {
+	name    string
+	owner   string
+	created time.Time
+
+	allow     *accessList
+	balancing pb.LoadBalancing
+	protocol  pb.Protocol
+	publicKey []byte // for end-to-end encryption (if used)
+
+	// lease expires the service after its last instance is removed.  Protected
+	// by the Server's mu.
+	lease *time.Timer
+
+	mu        sync.Mutex // protects instances, next, changed, deleted and draining
+	instances []*instance
+	next      int
+	changed   chan struct{} // closed when instances are added or the service is deleted
+	deleted   bool
+	draining  bool // not accepting new forwards (see DrainService)
 }
 
-// TokenSource is a simple token generator.
-type TokenSource struct {
-	mu sync.Mutex
+func newService(name, owner string, allow *accessList, balancing pb.LoadBalancing, protocol pb.Protocol, publicKey []byte) *service {
+	return &service{
+		name:      name,
+		owner:     owner,
+		allow:     allow,
+		balancing: balancing,
+		protocol:  protocol,
+		publicKey: publicKey,
+		created:   time.Now(),
+		changed:   make(chan struct{}),
+	}
+}
+
+func (s *service) addInstance() *instance {
+	defer s.mu.Unlock()
+	s.mu.Lock()
+
+	inst := newInstance()
+	s.instances = append(s.instances, inst)
 
-	buffer [32]byte
-	n      uint64
+	close(s.changed)
+	s.changed = make(chan struct{})
+	return inst
 }
 
-// Token returns a new token.
-func (t *TokenSource) Token() string {
-	defer t.mu.Unlock()
-	t.mu.Lock()
+// markDeleted marks the service as deleted, so that forwards waiting for
+// instances give up.
+func (s *service) markDeleted() {
+	defer s.mu.Unlock()
+	s.mu.Lock()
 
-	rand.Read(t.buffer[:24])
-	binary.LittleEndian.PutUint64(t.buffer[24:], t.n)
-	t.n++
+	if !s.deleted {
+		s.deleted = true
+		close(s.changed)
+	}
+}
 
-	return hex.EncodeToString(t.buffer[:])
+// closeInstances removes and closes all the instances of the service.
+func (s *service) closeInstances() {
+	defer s.mu.Unlock()
+	s.mu.Lock()
+
+	for _, inst := range s.instances {
+		inst.close()
+	}
+	s.instances = nil
 }
 
-var _ pb.ControlServiceServer = (*Server)(nil)
+// drain marks the service as not accepting new forwards.
+func (s *service) drain() {
+	defer s.mu.Unlock()
+	s.mu.Lock()
 
-// service represents a service, and handles incoming
-// forwards via in.
-type service struct {
-	name    string
-	created time.Time
+	s.draining = true
+}
 
-	in chan *forward
+func (s *service) isDraining() bool {
+	defer s.mu.Unlock()
+	s.mu.Lock()
+
+	return s.draining
 }
 
-func newService(name string) *service {
-	return &service{
-		name:    name,
-		created: time.Now(),
-		in:      make(chan *forward),
+// removeInstance removes the instance from the service, and returns the number
+// of remaining instances and whether inst was removed (false if it had already
+// been removed).
+func (s *service) removeInstance(inst *instance) (int, bool) {
+	defer s.mu.Unlock()
+	s.mu.Lock()
+
+	removed := false
+	for i, x := range s.instances {
+		if x == inst {
+			s.instances = append(s.instances[:i], s.instances[i+1:]...)
+			removed = true
+			break
+		}
 	}
+	inst.close()
+	return len(s.instances), removed
 }
 
-func (s *service) waitForFwd() <-chan *forward {
-	return s.in
+func (s *service) numInstances() int {
+	defer s.mu.Unlock()
+	s.mu.Lock()
+
+	return len(s.instances)
+}
+
+// pick an instance to handle a forward, using the load balancing policy of
+// the service.  If there are no instances then pick returns a channel which
+// is closed when an instance is added, or nil if the service has been
+// deleted.
+func (s *service) pick() (*instance, <-chan struct{}) {
+	defer s.mu.Unlock()
+	s.mu.Lock()
+
+	if len(s.instances) == 0 {
+		if s.deleted {
+			return nil, nil
+		}
+		return nil, s.changed
+	}
+	return pick(s.balancing, s.instances, &s.next), nil
+}
+
+// allows returns true if the caller identified by id, connecting from addr, is
+// allowed to forward to the service.
+func (s *service) allows(id *Identity, addr net.Addr) bool {
+	if s.ownedBy(id) {
+		return true
+	}
+	return s.allow.allows(id, addr)
+}
+
+// ownedBy returns true if the caller identified by id owns the service.
+func (s *service) ownedBy(id *Identity) bool {
+	return id.Name != "" && id.Name == s.owner
+}
+
+// record returns the definition of the service for a Registry.
+func (s *service) record() *ServiceRecord {
+	return &ServiceRecord{
+		Name:          s.name,
+		Owner:         s.owner,
+		Created:       s.created,
+		Allow:         s.allow.x,
+		LoadBalancing: s.balancing,
+		Protocol:      s.protocol,
+		PublicKey:     s.publicKey,
+	}
 }
 
 func (s *service) String() string {
-	return fmt.Sprintf("svc[name:%q,created:%v]", s.name, s.created)
+	return fmt.Sprintf("svc[name:%q,owner:%q,created:%v]", s.name, s.owner, s.created)
+}
+
+// instance is a single registration of a service (i.e. a CreateService
+// stream), and handles incoming forwards via in.
+type instance struct {
+	in chan *forward
+
+	active int64 // number of active forwards, accessed atomically
+
+	doneOnce sync.Once
+	done     chan struct{}
+}
+
+func newInstance() *instance {
+	return &instance{
+		in:   make(chan *forward),
+		done: make(chan struct{}),
+	}
+}
+
+func (i *instance) waitForFwd() <-chan *forward {
+	return i.in
+}
+
+func (i *instance) close() {
+	i.doneOnce.Do(func() {
+		close(i.done)
+	})
 }
 
 // forward is a handler for incoming forwards.
 type forward struct {
+	up, down int64 // bytes read from conn and the service connection, accessed atomically
+	state    int32 // pb.ConnectionState, accessed atomically
+
 	service string
 
+	// Caller which created the forward.
+	peer *pb.Peer
+
+	// Time the forward's token expires (if unclaimed).
+	expires time.Time
+
+	// ID of the connection, and the time it arrived (see connect).
+	id    uint64
+	start time.Time
+
 	conn net.Conn
+
+	closeOnce sync.Once
+	closed    chan struct{}
+}
+
+// pendingService is a forward waiting for the proxy connection from its
+// service to claim the token.  Service tokens are removed by the forward
+// (see handleForward), and swept if they expire first.
+type pendingService struct {
+	ch      chan net.Conn // buffered, so claiming never blocks
+	expires time.Time
+
+	// Claims of the token.
+	service, identity string
+
+	swept bool // removed by removeExpiredTokens, protected by the Server's mu
 }
 
-func newForward(service string) *forward {
+func newForward(service string, peer *pb.Peer) *forward {
 	return &forward{
 		service: service,
+		peer:    peer,
+		closed:  make(chan struct{}),
+	}
+}
+
+// close the caller's connection, and signal anything handling the forward to
+// give up.
+func (f *forward) close() {
+	f.closeOnce.Do(func() {
+		close(f.closed)
+		f.conn.Close()
+	})
+}
+
+func (f *forward) setState(state pb.ConnectionState) {
+	atomic.StoreInt32(&f.state, int32(state))
+}
+
+// connection returns the forward as a pb.Connection.
+func (f *forward) connection() *pb.Connection {
+	return &pb.Connection{
+		Id:        f.id,
+		Service:   f.service,
+		Peer:      f.peer,
+		StartTime: timestamppb.New(f.start),
+		BytesUp:   atomic.LoadInt64(&f.up),
+		BytesDown: atomic.LoadInt64(&f.down),
+		State:     pb.ConnectionState(atomic.LoadInt32(&f.state)),
 	}
 }
 
 func (f *forward) String() string {
-	return fmt.Sprintf("fwd[service:%q]", f.service)
+	return fmt.Sprintf("fwd[service:%q,peer:%q]", f.service, f.peer.GetAddr())
+}
+
+// ServerOption configures a Server.
+type ServerOption func(*Server)
+
+// DefaultRestoreLease is the lease given to services restored from the
+// registry when no lease grace is set (see WithLeaseGrace).
+const DefaultRestoreLease = 30 * time.Second
+
+// WithLeaseGrace sets the time for which a service is kept after its last
+// instance is removed (i.e. when the client disconnects).  During this time
+// the name is reserved for the owner, and forwards to the service are queued
+// until an instance is added (or the lease expires).  By default services
+// are removed immediately.
+func WithLeaseGrace(d time.Duration) ServerOption {
+	return func(s *Server) {
+		s.leaseGrace = d
+	}
+}
+
+// WithRegistry sets the Registry used to store service definitions.  Services
+// in the registry are restored when the Server is created, without instances
+// and so with a lease (see WithLeaseGrace, or DefaultRestoreLease if it isn't
+// set) during which their owners can reconnect.  Defaults to
+// NewMemoryRegistry.
+func WithRegistry(r Registry) ServerOption {
+	return func(s *Server) {
+		s.registry = r
+	}
+}
+
+// WithAuthenticator sets the Authenticator used to identify callers of
+// the control service.  By default all callers are anonymous.
+func WithAuthenticator(a Authenticator) ServerOption {
+	return func(s *Server) {
+		s.auth = a
+	}
+}
+
+// WithTokenTTL sets the time allowed for proxy connections to claim their
+// tokens, after which the forward is abandoned.  Defaults to DefaultTokenTTL,
+// which is also used if d isn't positive.
+func WithTokenTTL(d time.Duration) ServerOption {
+	return func(s *Server) {
+		if d > 0 {
+			s.tokenTTL = d
+		}
+	}
 }
 
 // NewServer creates a new Server.
-func NewServer(proxyDial string) *Server {
-	return &Server{
+func NewServer(proxyDial string, opts ...ServerOption) *Server {
+	m := newServerMetrics()
+	s := &Server{
+		tokens:        tokenStats{counter: m.tokens},
+		metrics:       m,
 		ts:            NewTokenSource(),
+		tokenTTL:      DefaultTokenTTL,
 		proxyDial:     proxyDial,
 		services:      make(map[string]*service),
-		serviceTokens: make(map[string]chan net.Conn),
+		serviceTokens: make(map[string]*pendingService),
 		forwardTokens: make(map[string]*forward),
+		conns:         make(map[uint64]*forward),
+		done:          make(chan bool),
+		registry:      NewMemoryRegistry(),
+	}
+	for _, opt := range opts {
+		opt(s)
 	}
+	s.joinCluster()
+	s.restore()
+	go s.sweepTokens()
+	return s
 }
 
 func RegisterServer(gs *grpc.Server, s *Server) {
 	pb.RegisterControlServiceServer(gs, s)
+	pb.RegisterAdminServiceServer(gs, s)
 }
 
 // Server.
 type Server struct {
+	tokens tokenStats // first, for 64-bit alignment of atomics
+
 	proxyDial string
 	ts        *TokenSource
+	tokenTTL  time.Duration
+	auth      Authenticator
+
+	metrics *serverMetrics
+
+	leaseGrace time.Duration
+	registry   Registry
+	cluster    *clusterMember
+	admins     *accessList
 
-	mu            sync.RWMutex        // protects services, serviceToken and forwardTokens
+	storeMu sync.Mutex // serializes creating and forgetting services (see addInstance)
+
+	mu            sync.RWMutex        // protects services, serviceToken, forwardTokens, conns and lastConnID
 	services      map[string]*service // name -> service
-	serviceTokens map[string]chan net.Conn
+	serviceTokens map[string]*pendingService
 	forwardTokens map[string]*forward
+	conns         map[uint64]*forward // id -> connected forward
+	lastConnID    uint64
 
 	doneOnce sync.Once
 	done     chan bool
 
 	pb.UnimplementedControlServiceServer
+	pb.UnimplementedAdminServiceServer
+}
+
+// authenticate identifies the caller of the request with ctx.
+func (s *Server) authenticate(ctx context.Context) (*Identity, error) {
+	if id, ok := ctx.Value(relayedIdentity{}).(*Identity); ok {
+		return id, nil
+	}
+	if s.auth == nil {
+		return anonymous, nil
+	}
+	return s.auth.Authenticate(ctx)
 }
 
 // serviceFromToken identifies an incoming proxy connection as coming from
-// a service.
-func (s *Server) serviceFromToken(token string) (chan<- net.Conn, bool) {
+// a service.  The claims of the token must match the pending service.
+func (s *Server) serviceFromToken(token string, c tokenClaims) (chan<- net.Conn, bool) {
 	defer s.mu.Unlock()
 	s.mu.Lock()
 
-	ch, ok := s.serviceTokens[token]
-	if ok {
-		delete(s.serviceTokens, token)
+	p, ok := s.serviceTokens[token]
+	if !ok || p.service != c.service || p.identity != c.identity {
+		return nil, false
 	}
-	return ch, ok
+	delete(s.serviceTokens, token)
+	return p.ch, true
 }
 
 // forwardFromToken identifies an incoming proxy connection as coming from a
-// forward request.
-func (s *Server) forwardFromToken(token string) (*forward, bool) {
+// forward request.  The claims of the token must match the forward.
+func (s *Server) forwardFromToken(token string, c tokenClaims) (*forward, bool) {
 	defer s.mu.Unlock()
 	s.mu.Lock()
 
 	fwd, ok := s.forwardTokens[token]
-	if ok {
-		delete(s.forwardTokens, token)
+	if !ok || fwd.service != c.service || fwd.peer.GetIdentity() != c.identity {
+		return nil, false
 	}
-	return fwd, ok
+	delete(s.forwardTokens, token)
+	return fwd, true
 }
 
 // ProxyListen starts the net.Listener l and handles the incoming connections
-// as proxy connections.
+// as proxy connections.  If l has metrics (i.e. a protoproxy.Server) they are
+// included in the Server's (see MetricsHandler).
 func (s *Server) ProxyListen(l net.Listener) error {
+	if m, ok := l.(interface{ Metrics() *metrics.Registry }); ok {
+		s.metrics.registry.Include(m.Metrics())
+	}
+
 	for {
 		c, err := l.Accept()
 		if err != nil {
@@ -169,49 +465,343 @@ func (s *Server) handleProxyConn(c net.Conn) {
 	}
 	token := h.GetToken()
 
-	if ch, ok := s.serviceFromToken(token); ok {
-		select {
-		case ch <- c:
-		case <-s.done:
-			log.Printf("Could not connect service: server closed")
+	router, t := splitToken(token)
+	if s.cluster != nil && router != "" && router != s.cluster.ID {
+		s.relayProxyConn(router, h, c)
+		return
+	}
+
+	claims, err := s.ts.verify(t)
+	if err != nil {
+		// Expired tokens are counted when they are removed.
+		if err != errTokenExpired {
+			s.tokens.invalidate()
 		}
+		log.Printf("Rejected token %q: %v", token, err)
+		c.Close()
 		return
 	}
 
-	if fwd, ok := s.forwardFromToken(token); ok {
-		fwd.conn = c
+	switch claims.kind {
+	case serviceToken:
+		if ch, ok := s.serviceFromToken(token, claims); ok {
+			s.tokens.claim()
+			ch <- c
+			return
+		}
 
-		// Lookup the service for this forward (check that it's still available).
-		svc, ok := s.getService(fwd.service)
-		if !ok {
-			log.Printf("No service for forward %v", fwd)
-			c.Close()
+	case forwardToken:
+		if fwd, ok := s.forwardFromToken(token, claims); ok {
+			s.tokens.claim()
+			s.connect(fwd, c)
 			return
 		}
+	}
+
+	s.tokens.invalidate()
+	log.Printf("Unknown token: %q", token)
+	c.Close()
+}
+
+// connect the forward to c (the caller's connection) and deliver it.  The
+// forward is listed as a connection until it's disconnected.
+func (s *Server) connect(fwd *forward, c net.Conn) {
+	fwd.conn = c
+	fwd.start = time.Now()
+
+	s.mu.Lock()
+	s.lastConnID++
+	fwd.id = s.lastConnID
+	s.conns[fwd.id] = fwd
+	s.mu.Unlock()
+
+	s.deliver(fwd)
+}
+
+// disconnect closes the forward.  If it was the last connection to a
+// draining service, the service is deleted.
+func (s *Server) disconnect(fwd *forward) {
+	fwd.close()
+
+	s.mu.Lock()
+	delete(s.conns, fwd.id)
+	svc, ok := s.services[fwd.service]
+	drained := ok && svc.isDraining() && len(s.serviceConns(fwd.service)) == 0
+	if drained {
+		log.Printf("Service %q drained", svc.name)
+		s.removeService(svc)
+	}
+	s.mu.Unlock()
+
+	if drained {
+		s.forgetService(svc)
+	}
+}
+
+// serviceConns returns the connections to the service name.  Must be called
+// with mu held.
+func (s *Server) serviceConns(name string) []*forward {
+	var out []*forward
+	for _, fwd := range s.conns {
+		if fwd.service == name {
+			out = append(out, fwd)
+		}
+	}
+	return out
+}
+
+// deliver the forward to an instance of its service.  If the chosen instance
+// goes away before accepting the forward, another is tried.  If the service
+// has no instances (but has not been deleted, see WithLeaseGrace) then the
+// forward waits for one.
+func (s *Server) deliver(fwd *forward) {
+	// Lookup the service for this forward (check that it's still available).
+	svc, ok := s.getService(fwd.service)
+	if !ok {
+		log.Printf("No service for forward %v", fwd)
+		s.disconnect(fwd)
+		return
+	}
+
+	for {
+		inst, wait := svc.pick()
+		if inst == nil {
+			if wait == nil {
+				log.Printf("No instances of service for forward %v", fwd)
+				s.disconnect(fwd)
+				return
+			}
+
+			select {
+			case <-wait:
+				continue
+			case <-fwd.closed:
+				s.disconnect(fwd)
+				return
+			case <-s.done:
+				log.Printf("Could not connect forward: server closed")
+				s.disconnect(fwd)
+				return
+			}
+		}
 
 		select {
-		case svc.in <- fwd:
+		case inst.in <- fwd:
+			return
+		case <-inst.done:
+			log.Printf("Instance of service closed, retrying forward %v", fwd)
+		case <-fwd.closed:
+			s.disconnect(fwd)
+			return
 		case <-s.done:
 			log.Printf("Could not connect forward: server closed")
+			s.disconnect(fwd)
+			return
 		}
-		return
 	}
+}
 
-	log.Printf("Unknown token: %q", token)
-	c.Close()
+// addInstance adds an instance to the service name, creating the service if
+// it doesn't exist.  Services can only have instances added by their owner,
+// and all instances must carry the same protocol and public key.  Errors are
+// gRPC status errors.
+func (s *Server) addInstance(name, owner string, allow *accessList, balancing pb.LoadBalancing, protocol pb.Protocol, publicKey []byte) (*service, *instance, error) {
+	if svc, inst, err := s.addExistingInstance(name, owner, protocol, publicKey); svc != nil || err != nil {
+		return svc, inst, err
+	}
+
+	// Creating a service updates the cluster and registry (which may be
+	// external stores) without holding mu, so services are created (and
+	// forgotten, see forgetService) one at a time.
+	defer s.storeMu.Unlock()
+	s.storeMu.Lock()
+
+	if svc, inst, err := s.addExistingInstance(name, owner, protocol, publicKey); svc != nil || err != nil {
+		return svc, inst, err
+	}
+
+	svc := newService(name, owner, allow, balancing, protocol, publicKey)
+	if err := s.announce(svc); err != nil {
+		return nil, nil, err
+	}
+	if err := s.registry.Put(svc.record()); err != nil {
+		s.unannounce(svc)
+		return nil, nil, status.Errorf(codes.Internal, "could not store service %q: %v", name, err)
+	}
+
+	defer s.mu.Unlock()
+	s.mu.Lock()
+
+	s.services[name] = svc
+	s.metrics.services.With().Inc()
+	return svc, svc.addInstance(), nil
 }
 
-func (s *Server) getOrCreateService(name string) (*service, bool) {
+// addExistingInstance adds an instance to the service name if it exists,
+// otherwise returns a nil service (and error).
+func (s *Server) addExistingInstance(name, owner string, protocol pb.Protocol, publicKey []byte) (*service, *instance, error) {
 	defer s.mu.Unlock()
 	s.mu.Lock()
 
-	if _, ok := s.services[name]; ok {
-		return nil, false
+	svc, ok := s.services[name]
+	if !ok {
+		return nil, nil, nil
+	}
+	if svc.owner != owner || svc.protocol != protocol || !bytes.Equal(svc.publicKey, publicKey) {
+		return nil, nil, status.Errorf(codes.AlreadyExists, "service %q already exists", name)
+	}
+	if svc.lease != nil {
+		svc.lease.Stop()
+		svc.lease = nil
 	}
+	return svc, svc.addInstance(), nil
+}
 
-	svc := newService(name)
-	s.services[name] = svc
-	return svc, true
+// removeInstance removes the instance from the service.  If there are no more
+// instances then the service is deleted, either immediately or when its lease
+// expires (see WithLeaseGrace).  Removing an instance more than once has no
+// effect.
+func (s *Server) removeInstance(svc *service, inst *instance) {
+	s.mu.Lock()
+	if n, removed := svc.removeInstance(inst); !removed || n > 0 || s.services[svc.name] != svc {
+		s.mu.Unlock()
+		return
+	}
+
+	if s.leaseGrace == 0 {
+		s.deleteService(svc)
+		s.mu.Unlock()
+		s.forgetService(svc)
+		return
+	}
+
+	log.Printf("Service %q has no instances, keeping for %v", svc.name, s.leaseGrace)
+	s.startLease(svc, s.leaseGrace)
+	s.mu.Unlock()
+}
+
+// startLease starts the lease for svc, which deletes it after d.  Must be
+// called with mu held.
+func (s *Server) startLease(svc *service, d time.Duration) {
+	var t *time.Timer
+	t = time.AfterFunc(d, func() {
+		s.mu.Lock()
+		// Services are kept in the registry when the Server is closed.
+		expired := svc.lease == t && !isDone(s.done)
+		if expired {
+			log.Printf("Lease for service %q expired", svc.name)
+			s.deleteService(svc)
+		}
+		s.mu.Unlock()
+
+		if expired {
+			s.forgetService(svc)
+		}
+	})
+	svc.lease = t
+}
+
+func isDone(ch <-chan bool) bool {
+	select {
+	case <-ch:
+		return true
+	default:
+		return false
+	}
+}
+
+// deleteService deletes svc.  Must be called with mu held, and followed by
+// forgetService once mu is released.
+func (s *Server) deleteService(svc *service) {
+	svc.lease = nil
+	svc.markDeleted()
+	if s.services[svc.name] == svc {
+		delete(s.services, svc.name)
+		s.metrics.services.With().Dec()
+		s.metrics.deleteService(svc.name)
+	}
+}
+
+// forgetService removes the deleted svc from the registry and the cluster
+// (unless a service with the same name has since been created).  Must be
+// called without mu held.
+func (s *Server) forgetService(svc *service) {
+	defer s.storeMu.Unlock()
+	s.storeMu.Lock()
+
+	if _, ok := s.getService(svc.name); ok {
+		return
+	}
+	if err := s.registry.Delete(svc.name); err != nil {
+		log.Printf("Could not delete service %q from registry: %v", svc.name, err)
+	}
+	s.unannounce(svc)
+}
+
+// announce that svc is hosted by this router to the cluster (if any).
+// Errors are gRPC status errors.
+func (s *Server) announce(svc *service) error {
+	if s.cluster == nil {
+		return nil
+	}
+	err := s.cluster.Cluster.AddService(svc.name, svc.owner, s.cluster.ID)
+	if err == ErrServiceOwned {
+		return status.Errorf(codes.AlreadyExists, "service %q already exists", svc.name)
+	}
+	if err != nil {
+		return status.Errorf(codes.Unavailable, "could not add service %q to cluster: %v", svc.name, err)
+	}
+	return nil
+}
+
+// unannounce that svc is hosted by this router.
+func (s *Server) unannounce(svc *service) {
+	if s.cluster == nil {
+		return
+	}
+	if err := s.cluster.Cluster.RemoveService(svc.name, s.cluster.ID); err != nil {
+		log.Printf("Could not remove service %q from cluster: %v", svc.name, err)
+	}
+}
+
+// restore the services in the registry.  They have no instances, and so are
+// deleted if their owners don't reconnect before their leases expire.
+func (s *Server) restore() {
+	records, err := s.registry.List()
+	if err != nil {
+		log.Printf("Could not list services in registry: %v", err)
+		return
+	}
+
+	lease := s.leaseGrace
+	if lease == 0 {
+		lease = DefaultRestoreLease
+	}
+
+	defer s.storeMu.Unlock()
+	s.storeMu.Lock()
+
+	for _, r := range records {
+		allow, err := newAccessList(r.Allow)
+		if err != nil {
+			log.Printf("Could not restore service %q: invalid access list: %v", r.Name, err)
+			continue
+		}
+
+		svc := newService(r.Name, r.Owner, allow, r.LoadBalancing, r.Protocol, r.PublicKey)
+		svc.created = r.Created
+		if err := s.announce(svc); err != nil {
+			log.Printf("Could not restore service %q: %v", r.Name, err)
+			continue
+		}
+
+		s.mu.Lock()
+		s.services[r.Name] = svc
+		s.metrics.services.With().Inc()
+		s.startLease(svc, lease)
+		s.mu.Unlock()
+		log.Printf("Restored service %q (owner: %q, lease: %v)", r.Name, r.Owner, lease)
+	}
 }
 
 func (s *Server) getService(name string) (*service, bool) {
@@ -222,49 +812,144 @@ func (s *Server) getService(name string) (*service, bool) {
 	return svc, ok
 }
 
-func (s *Server) deleteService(name string) {
+// newToken returns a new token carrying c, which expires after the token TTL.
+// In a cluster tokens are prefixed with the ID of the router, so that proxy
+// connections can be relayed to it.
+func (s *Server) newToken(c tokenClaims) string {
+	s.tokens.issue()
+	t := s.ts.sign(c)
+	if s.cluster != nil {
+		t = s.cluster.ID + "." + t
+	}
+	return t
+}
+
+func (s *Server) createServiceToken(svc *service) (string, *pendingService) {
+	p := &pendingService{
+		ch:       make(chan net.Conn, 1),
+		expires:  time.Now().Add(s.tokenTTL),
+		service:  svc.name,
+		identity: svc.owner,
+	}
+	token := s.newToken(tokenClaims{
+		kind:     serviceToken,
+		service:  p.service,
+		identity: p.identity,
+		expires:  p.expires,
+	})
+
 	defer s.mu.Unlock()
 	s.mu.Lock()
 
-	delete(s.services, name)
+	s.serviceTokens[token] = p
+	return token, p
 }
 
-func (s *Server) createServiceToken() (token string, ch <-chan net.Conn) {
-	token = s.ts.Token()
-	cch := make(chan net.Conn)
+func (s *Server) createForwardToken(service string, peer *pb.Peer) string {
+	fwd := newForward(service, peer)
+	fwd.expires = time.Now().Add(s.tokenTTL)
+	t := s.newToken(tokenClaims{
+		kind:     forwardToken,
+		service:  service,
+		identity: peer.GetIdentity(),
+		expires:  fwd.expires,
+	})
 
-	s.serviceTokens[token] = cch
-	return token, cch
+	defer s.mu.Unlock()
+	s.mu.Lock()
+
+	s.forwardTokens[t] = fwd
+	return t
 }
 
-func (s *Server) createForwardToken(service string) string {
-	t := s.ts.Token()
+// sweepTokens removes tokens which have expired without being claimed, until
+// the Server is closed.
+func (s *Server) sweepTokens() {
+	t := time.NewTicker(s.tokenTTL)
+	defer t.Stop()
 
+	for {
+		select {
+		case now := <-t.C:
+			if n := s.removeExpiredTokens(now); n > 0 {
+				log.Printf("Removed %d expired tokens", n)
+			}
+		case <-s.done:
+			return
+		}
+	}
+}
+
+// removeExpiredTokens removes tokens which expired before now.  Returns the
+// number removed.
+func (s *Server) removeExpiredTokens(now time.Time) int {
 	defer s.mu.Unlock()
 	s.mu.Lock()
 
-	s.forwardTokens[t] = newForward(service)
-	return t
+	n := 0
+	for t, fwd := range s.forwardTokens {
+		if now.After(fwd.expires) {
+			delete(s.forwardTokens, t)
+			n++
+		}
+	}
+	for t, p := range s.serviceTokens {
+		if now.After(p.expires) {
+			delete(s.serviceTokens, t)
+			p.swept = true
+			n++
+		}
+	}
+	s.tokens.expire(n)
+	return n
+}
+
+// TokenStats returns counts of the tokens issued by the Server.
+func (s *Server) TokenStats() TokenStats {
+	return s.tokens.stats()
 }
 
 // Create a service.
-func (s *Server) CreateService(r *pb.CreateServiceRequest, css pb.ControlService_CreateServiceServer) error {
+func (s *Server) CreateService(r *pb.CreateServiceRequest, css pb.ControlService_CreateServiceServer) (err error) {
+	start := time.Now()
+	defer func() {
+		s.metrics.createService.Observe(time.Since(start).Seconds(), status.Code(err).String())
+	}()
+
+	ctx := css.Context()
+	id, err := s.authenticate(ctx)
+	if err != nil {
+		return err
+	}
+
+	allow, err := newAccessList(r.GetAllow())
+	if err != nil {
+		return status.Errorf(codes.InvalidArgument, "invalid access list: %v", err)
+	}
+	if err := checkPublicKey(r.GetPublicKey()); err != nil {
+		return err
+	}
+
 	name := r.GetName()
-	svc, ok := s.getOrCreateService(name)
-	if !ok {
-		return status.Errorf(codes.AlreadyExists, "service %q already exists", name)
+	svc, inst, err := s.addInstance(name, id.Name, allow, r.GetLoadBalancing(), r.GetProtocol(), r.GetPublicKey())
+	if err != nil {
+		return err
 	}
 
 	defer func() {
-		s.deleteService(name)
+		s.removeInstance(svc, inst)
 	}()
 
-	log.Printf("Created service %q", name)
+	// Send headers so that the client knows the service has been created.
+	if err := css.SendHeader(metadata.MD{}); err != nil {
+		return err
+	}
+
+	log.Printf("Created %v service %q (owner: %v, instances: %d)", r.GetProtocol(), name, id, svc.numInstances())
 
-	ctx := css.Context()
 	for {
 		select {
-		case fwd, ok := <-svc.waitForFwd(): // forwarding request to the service
+		case fwd, ok := <-inst.waitForFwd(): // forwarding request to the service
 			if !ok {
 				log.Printf("service is closed")
 				return status.Errorf(codes.Unknown, "service is closed")
@@ -272,18 +957,32 @@ func (s *Server) CreateService(r *pb.CreateServiceRequest, css pb.ControlService
 
 			// Setup the service token to wait for the incoming connection
 			// from the service.
-			serviceToken, ch := s.createServiceToken()
+			token, p := s.createServiceToken(svc)
 
 			// Send the token to the service client, telling them to create a
 			// connection to serve the forward.
 			if err := css.Send(&pb.CreateServiceResponse{
-				Token:    serviceToken,
+				Token:    token,
 				DialAddr: s.proxyDial,
+				Peer:     fwd.peer,
 			}); err != nil {
+				// Hand the forward to another instance (if there is one).  The
+				// instance is removed first so that it isn't picked, the
+				// deferred removeInstance is then a no-op.
+				s.revokeServiceToken(token, p)
+				s.removeInstance(svc, inst)
+				go s.deliver(fwd)
 				return status.Errorf(codes.Unknown, "could not send service response: %v", err)
 			}
 
-			go s.handleForward(ctx, fwd, ch)
+			atomic.AddInt64(&inst.active, 1)
+			go func() {
+				defer atomic.AddInt64(&inst.active, -1)
+				s.handleForward(ctx, fwd, token, p)
+			}()
+
+		case <-inst.done: // the service was deleted (see DeleteService)
+			return status.Errorf(codes.Aborted, "service %q was deleted", name)
 
 		case <-ctx.Done():
 			return ctx.Err()
@@ -294,52 +993,254 @@ func (s *Server) CreateService(r *pb.CreateServiceRequest, css pb.ControlService
 	}
 }
 
-func (s *Server) handleForward(ctx context.Context, fwd *forward, ch <-chan net.Conn) {
-	defer fwd.conn.Close()
+func (s *Server) handleForward(ctx context.Context, fwd *forward, token string, p *pendingService) {
+	defer s.disconnect(fwd)
+	fwd.setState(pb.ConnectionState_CONNECTING)
+
+	expired := time.NewTimer(time.Until(p.expires))
+	defer expired.Stop()
 
 	var serviceConn net.Conn
 	select {
-	case serviceConn = <-ch: // wait for the service connection to arrive
+	case serviceConn = <-p.ch: // wait for the service connection to arrive
 		defer serviceConn.Close()
 
 	case <-s.done:
 		log.Printf("Could not handle forward: server closed")
 		return
 
+	case <-fwd.closed:
+		log.Printf("Forward closed waiting for outgoing service connection")
+		s.revokeServiceToken(token, p)
+		return
+
 	case <-ctx.Done():
-		log.Printf("timeout waiting for outgoing service connection")
+		log.Printf("Service ended waiting for outgoing service connection")
+		s.revokeServiceToken(token, p)
+		return
+
+	case <-expired.C:
+		log.Printf("Timeout waiting for outgoing service connection")
+		if s.revokeServiceToken(token, p) {
+			s.tokens.expire(1)
+		}
 		return
 	}
 
-	if err := copyUpDown(serviceConn, fwd.conn, s.done); err != nil {
+	fwd.setState(pb.ConnectionState_ACTIVE)
+
+	// Closing the forward (see CloseConnection) ends the copy.
+	go func() {
+		<-fwd.closed
+		serviceConn.Close()
+	}()
+
+	// The values are kept (rather than looked up again) as the series are
+	// removed when the service is deleted.
+	s.metrics.forwardsTotal.With(fwd.service).Inc()
+	active := s.metrics.forwards.With(fwd.service)
+	active.Inc()
+	defer active.Dec()
+
+	up := &countingConn{fwd.conn, &fwd.up, s.metrics.bytes.With(fwd.service, "up")}
+	down := &countingConn{serviceConn, &fwd.down, s.metrics.bytes.With(fwd.service, "down")}
+	if err := copyUpDown(down, up, s.done); err != nil {
 		log.Printf("FWD%v: %v", fwd, err)
 	}
 }
 
+// revokeServiceToken removes the token for p.  If the token has already been
+// claimed, the service connection is closed.  Returns true if the token was
+// removed (i.e. it was neither claimed nor swept).
+func (s *Server) revokeServiceToken(token string, p *pendingService) bool {
+	s.mu.Lock()
+	_, ok := s.serviceTokens[token]
+	delete(s.serviceTokens, token)
+	swept := p.swept
+	s.mu.Unlock()
+
+	if ok || swept {
+		return ok
+	}
+	c := <-p.ch
+	c.Close()
+	return false
+}
+
 // Forward to remote service.
 func (s *Server) ForwardToService(ctx context.Context, r *pb.ForwardToServiceRequest) (*pb.ForwardToServiceResponse, error) {
-	name := r.GetName()
-	if _, ok := s.getService(name); !ok {
-		return nil, status.Errorf(codes.NotFound, "service %q does not exist", name)
+	ctx, err := s.fromRelay(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// Services hosted by other routers are relayed (unless this is already
+	// a relayed request).
+	if _, ok := s.getService(r.GetName()); !ok && ctx.Value(relayedIdentity{}) == nil {
+		return s.relayForward(ctx, r)
 	}
 
-	token := s.createForwardToken(name)
+	if err := checkPublicKey(r.GetPublicKey()); err != nil {
+		return nil, err
+	}
+
+	svc, peer, err := s.authorizeForward(ctx, r.GetName(), r.GetProtocol(), r.GetPublicKey())
+	if err != nil {
+		return nil, err
+	}
+
+	token := s.createForwardToken(r.GetName(), peer)
 	return &pb.ForwardToServiceResponse{
-		Token:    token,
-		DialAddr: s.proxyDial,
+		Token:      token,
+		DialAddr:   s.proxyDial,
+		Peer:       peer,
+		ServiceKey: svc.publicKey,
 	}, nil
 }
 
+// DialService creates a connection to the service name from within the
+// router, without a proxy connection (or in a cluster, with one to the router
+// hosting the service).  The caller is authenticated (and checked against the
+// service's access list) using ctx in the same way as gRPC requests, so ctx
+// should carry the incoming metadata and peer of the caller.  Errors are gRPC
+// status errors.
+func (s *Server) DialService(ctx context.Context, name string) (net.Conn, error) {
+	if _, ok := s.getService(name); !ok && s.cluster != nil {
+		return s.dialRelayed(ctx, name)
+	}
+
+	_, peer, err := s.authorizeForward(ctx, name, pb.Protocol_TCP, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	c, fc := net.Pipe()
+	go s.connect(newForward(name, peer), fc)
+	return c, nil
+}
+
+// forwardConn forwards c, from a caller without credentials (i.e. a raw TLS
+// connection), to the service name.  The caller is anonymous, and so when the
+// Server has an Authenticator the service's access list must explicitly
+// allow the address of the caller.  Only services hosted by this router can be
+// reached (forwards aren't relayed in a cluster).  Errors are gRPC status
+// errors.
+func (s *Server) forwardConn(name string, c net.Conn) error {
+	svc, ok := s.getService(name)
+	if !ok {
+		return status.Errorf(codes.NotFound, "service %q does not exist", name)
+	}
+
+	addr := c.RemoteAddr()
+	allowed := svc.allows(anonymous, addr)
+	if s.auth != nil {
+		allowed = svc.allow.allowsAddr(addr)
+	}
+	if !allowed {
+		return status.Errorf(codes.NotFound, "service %q does not exist", name)
+	}
+
+	if svc.protocol != pb.Protocol_TCP {
+		return status.Errorf(codes.FailedPrecondition, "service %q is %v, not %v", name, svc.protocol, pb.Protocol_TCP)
+	}
+
+	if len(svc.publicKey) > 0 {
+		return status.Errorf(codes.FailedPrecondition, "service %q requires end-to-end encryption", name)
+	}
+
+	if svc.isDraining() {
+		return status.Errorf(codes.Unavailable, "service %q is draining", name)
+	}
+
+	log.Printf("Forwarding to service %q (caller: %v)", name, addr)
+
+	s.connect(newForward(name, &pb.Peer{Addr: addr.String()}), c)
+	return nil
+}
+
+// authorizeForward authenticates the caller of the request with ctx, and
+// checks that they are allowed to forward to the service name using protocol.
+// The caller's publicKey must be set if (and only if) the service uses
+// end-to-end encryption.  Returns the service and the caller as a pb.Peer.
+func (s *Server) authorizeForward(ctx context.Context, name string, protocol pb.Protocol, publicKey []byte) (*service, *pb.Peer, error) {
+	id, err := s.authenticate(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Services the caller isn't allowed to forward to are reported as not
+	// existing, so that callers can't discover them.
+	svc, ok := s.getService(name)
+	if !ok || !svc.allows(id, peerAddr(ctx)) {
+		return nil, nil, status.Errorf(codes.NotFound, "service %q does not exist", name)
+	}
+
+	if svc.isDraining() {
+		return nil, nil, status.Errorf(codes.Unavailable, "service %q is draining", name)
+	}
+
+	if svc.protocol != protocol {
+		return nil, nil, status.Errorf(codes.FailedPrecondition, "service %q is %v, not %v", name, svc.protocol, protocol)
+	}
+
+	switch {
+	case len(svc.publicKey) > 0 && len(publicKey) == 0:
+		return nil, nil, status.Errorf(codes.FailedPrecondition, "service %q requires end-to-end encryption", name)
+	case len(svc.publicKey) == 0 && len(publicKey) > 0:
+		return nil, nil, status.Errorf(codes.FailedPrecondition, "service %q does not use end-to-end encryption", name)
+	}
+
+	log.Printf("Forwarding to service %q (caller: %v)", name, id)
+
+	peer := &pb.Peer{
+		Identity:  id.Name,
+		PublicKey: publicKey,
+	}
+	if addr := peerAddr(ctx); addr != nil {
+		peer.Addr = addr.String()
+	}
+	return svc, peer, nil
+}
+
+// checkPublicKey returns an InvalidArgument error if key is set and isn't an
+// Ed25519 public key.
+func checkPublicKey(key []byte) error {
+	if len(key) != 0 && len(key) != ed25519.PublicKeySize {
+		return status.Errorf(codes.InvalidArgument, "invalid public key: must be %d bytes", ed25519.PublicKeySize)
+	}
+	return nil
+}
+
+// ListServices lists the services the caller is allowed to forward to.  Access
+// lists are only included for the services the caller owns.
 func (s *Server) ListServices(ctx context.Context, _ *pb.ListServicesRequest) (*pb.ListServicesResponse, error) {
+	id, err := s.authenticate(ctx)
+	if err != nil {
+		return nil, err
+	}
+	addr := peerAddr(ctx)
+
 	defer s.mu.RUnlock()
 	s.mu.RLock()
 
 	out := make([]*pb.Service, 0, len(s.services))
 	for _, v := range s.services {
-		out = append(out, &pb.Service{
-			Name:       v.name,
-			CreateTime: timestamppb.New(v.created),
-		})
+		if !v.allows(id, addr) {
+			continue
+		}
+		x := &pb.Service{
+			Name:          v.name,
+			CreateTime:    timestamppb.New(v.created),
+			Owner:         v.owner,
+			Instances:     int32(v.numInstances()),
+			LoadBalancing: v.balancing,
+			Protocol:      v.protocol,
+			PublicKey:     v.publicKey,
+		}
+		if v.ownedBy(id) {
+			x.Allow = v.allow.x
+		}
+		out = append(out, x)
 	}
 
 	return &pb.ListServicesResponse{
@@ -347,8 +1248,37 @@ func (s *Server) ListServices(ctx context.Context, _ *pb.ListServicesRequest) (*
 	}, nil
 }
 
+// ListConnections lists the forwards to services hosted by this router
+// (in a cluster, each router lists its own).  Admins see all connections,
+// other callers only those to the services they own.
+func (s *Server) ListConnections(ctx context.Context, _ *pb.ListConnectionsRequest) (*pb.ListConnectionsResponse, error) {
+	id, err := s.authenticate(ctx)
+	if err != nil {
+		return nil, err
+	}
+	admin := s.isAdmin(id)
+
+	defer s.mu.RUnlock()
+	s.mu.RLock()
+
+	out := make([]*pb.Connection, 0, len(s.conns))
+	for _, fwd := range s.conns {
+		if svc, ok := s.services[fwd.service]; admin || ok && svc.ownedBy(id) {
+			out = append(out, fwd.connection())
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].GetId() < out[j].GetId() })
+
+	return &pb.ListConnectionsResponse{
+		Connections: out,
+	}, nil
+}
+
 // Close shutsdown any running forwards.
 func (s *Server) Close() error {
-	s.doneOnce.Do(func() { close(s.done) })
+	s.doneOnce.Do(func() {
+		close(s.done)
+		s.leaveCluster()
+	})
 	return nil
 }
@@ -1,12 +1,90 @@
 package mindmeld_test
 
 import (
+	"context"
+	"io"
 	"testing"
+	"time"
+
+	"google.golang.org/grpc"
 
 	"github.com/dhowden/mindmeld"
+	"github.com/dhowden/mindmeld/pb"
 )
 
 func TestNewTokenSource(t *testing.T) {
 	ts := mindmeld.NewTokenSource()
 	_ = ts.Token()
 }
+
+// waitForConnections polls the router until ok returns true for its
+// connections, and returns them.
+func waitForConnections(t *testing.T, cc *grpc.ClientConn, ok func([]*pb.Connection) bool) []*pb.Connection {
+	t.Helper()
+
+	var conns []*pb.Connection
+	poll(t, "connections", func() (bool, error) {
+		resp, err := pb.NewControlServiceClient(cc).ListConnections(context.Background(), &pb.ListConnectionsRequest{})
+		if err != nil {
+			return false, err
+		}
+		conns = resp.GetConnections()
+		return ok(conns), nil
+	})
+	return conns
+}
+
+func TestListConnections(t *testing.T) {
+	r := newAuthRouter(t, map[string]*mindmeld.Identity{
+		"alice": {Name: "alice"},
+		"bob":   {Name: "bob"},
+		"carol": {Name: "carol"},
+	}, mindmeld.WithAdmins(&pb.AccessList{Identities: []string{"carol"}}))
+	alice, bob, carol := tokenConn(t, r, "alice"), tokenConn(t, r, "bob"), tokenConn(t, r, "carol")
+
+	l, err := mindmeld.Listen(context.Background(), alice, "svc")
+	if err != nil {
+		t.Fatalf("Listen() = %v", err)
+	}
+	defer l.Close()
+	go serveName(l, "svc")
+	waitForService(t, alice, "svc")
+
+	c, _ := dialName(t, bob, "svc")
+	if _, err := io.WriteString(c, "hello"); err != nil {
+		t.Fatalf("could not write: %v", err)
+	}
+
+	conns := waitForConnections(t, alice, func(conns []*pb.Connection) bool {
+		return len(conns) == 1 && conns[0].GetBytesUp() == 5
+	})
+	got := conns[0]
+	if got.GetService() != "svc" || got.GetPeer().GetIdentity() != "bob" || got.GetState() != pb.ConnectionState_ACTIVE || got.GetBytesDown() != 3 {
+		t.Errorf("ListConnections() = %v, expected active connection to %q from %q with 3 bytes down", got, "svc", "bob")
+	}
+	if d := time.Since(got.GetStartTime().AsTime()); d < 0 || d > time.Minute {
+		t.Errorf("start time %v is not recent", got.GetStartTime().AsTime())
+	}
+
+	// Connections are listed for the owner of the service and for admins,
+	// but not for other callers.
+	for _, tt := range []struct {
+		name string
+		cc   *grpc.ClientConn
+		want int
+	}{
+		{"bob", bob, 0},
+		{"carol", carol, 1},
+	} {
+		resp, err := pb.NewControlServiceClient(tt.cc).ListConnections(context.Background(), &pb.ListConnectionsRequest{})
+		if err != nil {
+			t.Fatalf("ListConnections() = %v", err)
+		}
+		if got := len(resp.GetConnections()); got != tt.want {
+			t.Errorf("ListConnections() for %v = %d connections, expected %d", tt.name, got, tt.want)
+		}
+	}
+
+	c.Close()
+	waitForConnections(t, alice, func(conns []*pb.Connection) bool { return len(conns) == 0 })
+}
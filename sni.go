@@ -0,0 +1,132 @@
+package mindmeld
+
+import (
+	"bytes"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"time"
+)
+
+// NewSNIProxy creates an SNIProxy for services registered with s.  Connections
+// for server name "<service>.<domain>" are routed to service.  If domain is
+// empty then the server name is the service.
+func NewSNIProxy(s *Server, domain string) *SNIProxy {
+	return &SNIProxy{
+		s:      s,
+		domain: domain,
+	}
+}
+
+// SNIProxy accepts TLS connections and routes them to services by the server
+// name (SNI) in the ClientHello.  TLS is not terminated: the connection is
+// passed to the service as-is, and so the service must handle TLS itself.
+//
+// Callers don't have credentials, and so are anonymous (see
+// Server.forwardConn).
+type SNIProxy struct {
+	s      *Server
+	domain string
+}
+
+// Serve accepts connections from l and routes them to services.
+func (p *SNIProxy) Serve(l net.Listener) error {
+	for {
+		c, err := l.Accept()
+		if err != nil {
+			return fmt.Errorf("could not accept incoming connection: %w", err)
+		}
+
+		go p.handleConn(c)
+	}
+}
+
+// Maximum time allowed for the client to send the ClientHello.
+const sniTimeout = 10 * time.Second
+
+func (p *SNIProxy) handleConn(c net.Conn) {
+	c.SetReadDeadline(time.Now().Add(sniTimeout))
+	serverName, hello, err := peekServerName(c)
+	c.SetReadDeadline(time.Time{})
+	if err != nil {
+		log.Printf("Could not read server name from %v: %v", c.RemoteAddr(), err)
+		c.Close()
+		return
+	}
+
+	name := serviceFromHost(serverName, p.domain)
+	if name == "" {
+		log.Printf("No service for server name %q from %v", serverName, c.RemoteAddr())
+		c.Close()
+		return
+	}
+
+	rc := &replayConn{
+		Conn: c,
+		r:    io.MultiReader(bytes.NewReader(hello), c),
+	}
+	if err := p.s.forwardConn(name, rc); err != nil {
+		log.Printf("Could not forward TLS connection from %v: %v", c.RemoteAddr(), err)
+		c.Close()
+	}
+}
+
+// errHelloRead is used to stop the TLS handshake once the ClientHello has
+// been read.
+var errHelloRead = errors.New("client hello read")
+
+// peekServerName reads the TLS ClientHello from c, and returns the server
+// name along with the bytes read (so that they can be replayed).
+func peekServerName(c net.Conn) (string, []byte, error) {
+	var buf bytes.Buffer
+	var serverName string
+	err := tls.Server(readOnlyConn{io.TeeReader(c, &buf)}, &tls.Config{
+		GetConfigForClient: func(hello *tls.ClientHelloInfo) (*tls.Config, error) {
+			serverName = hello.ServerName
+			return nil, errHelloRead
+		},
+	}).Handshake()
+	if serverName == "" {
+		if err == nil || errors.Is(err, errHelloRead) {
+			err = errors.New("no server name")
+		}
+		return "", nil, err
+	}
+	return serverName, buf.Bytes(), nil
+}
+
+// readOnlyConn is a net.Conn which can only be read from.  Writes fail, and
+// all other methods are no-ops.
+type readOnlyConn struct {
+	r io.Reader
+}
+
+func (c readOnlyConn) Read(b []byte) (int, error)       { return c.r.Read(b) }
+func (readOnlyConn) Write(b []byte) (int, error)        { return 0, io.ErrClosedPipe }
+func (readOnlyConn) Close() error                       { return nil }
+func (readOnlyConn) LocalAddr() net.Addr                { return nil }
+func (readOnlyConn) RemoteAddr() net.Addr               { return nil }
+func (readOnlyConn) SetDeadline(t time.Time) error      { return nil }
+func (readOnlyConn) SetReadDeadline(t time.Time) error  { return nil }
+func (readOnlyConn) SetWriteDeadline(t time.Time) error { return nil }
+
+// replayConn is a net.Conn which reads from r (rather than the underlying
+// connection).
+type replayConn struct {
+	net.Conn
+	r io.Reader
+}
+
+func (c *replayConn) Read(b []byte) (int, error) { return c.r.Read(b) }
+
+// CloseWrite closes the writing side of the underlying connection (if
+// supported), so that half-close is propagated.
+func (c *replayConn) CloseWrite() error {
+	if cw, ok := c.Conn.(closeWriter); ok {
+		return cw.CloseWrite()
+	}
+	return nil
+}
@@ -0,0 +1,120 @@
+package mindmeld_test
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"google.golang.org/grpc"
+
+	"github.com/dhowden/mindmeld"
+	"github.com/dhowden/mindmeld/pb"
+)
+
+// serveTLS registers service name, forwarding to a TLS server which
+// writes name in response to HTTP requests.
+func serveTLS(t *testing.T, cc *grpc.ClientConn, name string, opts ...mindmeld.Option) {
+	t.Helper()
+
+	hs := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, name)
+	}))
+	t.Cleanup(hs.Close)
+
+	sc := mindmeld.NewServiceClient(cc, name, hs.Listener.Addr().String(), opts...)
+	t.Cleanup(func() { sc.Close() })
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	go sc.Register(ctx)
+
+	waitForService(t, cc, name)
+}
+
+// sniProxy starts an SNIProxy for r, returning its address.
+func sniProxy(t *testing.T, r *TestRouter, domain string) string {
+	t.Helper()
+
+	l, err := net.Listen("tcp", "localhost:0")
+	if err != nil {
+		t.Fatalf("could not listen: %v", err)
+	}
+	t.Cleanup(func() { l.Close() })
+	go mindmeld.NewSNIProxy(r.Server, domain).Serve(l)
+	return l.Addr().String()
+}
+
+// getTLS makes an HTTP request over TLS to addr using serverName, returning
+// the response body.
+func getTLS(addr, serverName string) (string, error) {
+	c, err := tls.Dial("tcp", addr, &tls.Config{
+		ServerName:         serverName,
+		InsecureSkipVerify: true,
+	})
+	if err != nil {
+		return "", err
+	}
+	defer c.Close()
+
+	io.WriteString(c, "GET / HTTP/1.0\r\n\r\n")
+	b, err := ioutil.ReadAll(c)
+	if err != nil {
+		return "", err
+	}
+	s := string(b)
+	return s[strings.Index(s, "\r\n\r\n")+4:], nil
+}
+
+func TestSNIProxy(t *testing.T) {
+	r := NewTestRouter(t)
+	cc := r.ClientConn(t)
+	serveTLS(t, cc, "a")
+	serveTLS(t, cc, "b")
+
+	addr := sniProxy(t, r, "dev.example.com")
+
+	for _, name := range []string{"a", "b"} {
+		got, err := getTLS(addr, name+".dev.example.com")
+		if err != nil {
+			t.Fatalf("request for %q failed: %v", name, err)
+		}
+		if got != name {
+			t.Errorf("request for %q got %q", name, got)
+		}
+	}
+
+	for _, serverName := range []string{"missing.dev.example.com", "a.other.example.com"} {
+		if _, err := getTLS(addr, serverName); err == nil {
+			t.Errorf("request for %q succeeded, expected error", serverName)
+		}
+	}
+}
+
+func TestSNIProxyAuth(t *testing.T) {
+	r := newAuthRouter(t, map[string]*mindmeld.Identity{
+		"secret": {Name: "alice"},
+	})
+	cc := tokenConn(t, r, "secret")
+
+	// Without credentials callers must be allowed by address.
+	serveTLS(t, cc, "open")
+	serveTLS(t, cc, "local", mindmeld.WithAccessList(&pb.AccessList{
+		Cidrs: []string{"127.0.0.0/8", "::1/128"},
+	}))
+
+	addr := sniProxy(t, r, "")
+
+	if got, err := getTLS(addr, "local"); err != nil || got != "local" {
+		t.Errorf("getTLS(%q) = %q, %v, expected %q", "local", got, err, "local")
+	}
+	if _, err := getTLS(addr, "open"); err == nil {
+		t.Errorf("request for %q succeeded, expected error", "open")
+	}
+}
@@ -0,0 +1,232 @@
+package mindmeld
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// SOCKSDomain is the domain of hostnames which SOCKSProxy resolves to
+// services: "<service>.mindmeld".
+const SOCKSDomain = "mindmeld"
+
+// NewSOCKSProxy creates a SOCKSProxy which creates connections using d.
+func NewSOCKSProxy(d *Dialer) *SOCKSProxy {
+	return &SOCKSProxy{
+		d: d,
+	}
+}
+
+// SOCKSProxy is a local SOCKS5 and HTTP CONNECT proxy which connects
+// to services by hostname ("<service>.mindmeld", see SOCKSDomain), so that
+// a single proxy can be used to reach all services.  Ports are ignored.
+type SOCKSProxy struct {
+	d *Dialer
+}
+
+// Serve accepts connections from l and proxies them to services.
+func (p *SOCKSProxy) Serve(l net.Listener) error {
+	for {
+		c, err := l.Accept()
+		if err != nil {
+			return fmt.Errorf("could not accept incoming connection: %w", err)
+		}
+
+		go p.handleConn(c)
+	}
+}
+
+// Maximum time allowed to connect to a service.
+const socksDialTimeout = 10 * time.Second
+
+func (p *SOCKSProxy) handleConn(c net.Conn) {
+	defer c.Close()
+
+	br := bufio.NewReader(c)
+	b, err := br.Peek(1)
+	if err != nil {
+		return
+	}
+
+	var fconn net.Conn
+	if b[0] == socks5Version {
+		fconn, err = p.handleSOCKS5(c, br)
+	} else {
+		fconn, err = p.handleConnect(c, br)
+	}
+	if err != nil {
+		log.Printf("Could not proxy connection from %v: %v", c.RemoteAddr(), err)
+		return
+	}
+	defer fconn.Close()
+
+	if err := copyUpDown(fconn, &replayConn{Conn: c, r: br}, nil); err != nil {
+		log.Printf("Forwarding ended: %v", err)
+	}
+}
+
+// dial the service for host ("<service>.mindmeld").
+func (p *SOCKSProxy) dial(host string) (net.Conn, error) {
+	name := serviceFromHost(host, SOCKSDomain)
+	if name == "" {
+		return nil, status.Errorf(codes.PermissionDenied, "%q is not in domain %q", host, SOCKSDomain)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), socksDialTimeout)
+	defer cancel()
+
+	return p.d.DialContext(ctx, "tcp", name)
+}
+
+// SOCKS5 protocol constants (RFC 1928).
+const (
+	socks5Version = 0x05
+
+	socks5NoAuth       = 0x00
+	socks5NoAcceptable = 0xff
+
+	socks5Connect = 0x01
+
+	socks5IPv4   = 0x01
+	socks5Domain = 0x03
+
+	socks5Succeeded          = 0x00
+	socks5Failure            = 0x01
+	socks5NotAllowed         = 0x02
+	socks5HostUnreachable    = 0x04
+	socks5CmdNotSupported    = 0x07
+	socks5AddrTypeNotSupport = 0x08
+)
+
+func (p *SOCKSProxy) handleSOCKS5(c net.Conn, br *bufio.Reader) (net.Conn, error) {
+	// Greeting: VER NMETHODS METHODS...
+	var hdr [2]byte
+	if _, err := io.ReadFull(br, hdr[:]); err != nil {
+		return nil, fmt.Errorf("could not read greeting: %w", err)
+	}
+	methods := make([]byte, hdr[1])
+	if _, err := io.ReadFull(br, methods); err != nil {
+		return nil, fmt.Errorf("could not read methods: %w", err)
+	}
+	if !bytesContain(methods, socks5NoAuth) {
+		c.Write([]byte{socks5Version, socks5NoAcceptable})
+		return nil, errors.New("client does not support unauthenticated SOCKS5")
+	}
+	if _, err := c.Write([]byte{socks5Version, socks5NoAuth}); err != nil {
+		return nil, fmt.Errorf("could not write method: %w", err)
+	}
+
+	// Request: VER CMD RSV ATYP DST.ADDR DST.PORT
+	var req [4]byte
+	if _, err := io.ReadFull(br, req[:]); err != nil {
+		return nil, fmt.Errorf("could not read request: %w", err)
+	}
+	if req[1] != socks5Connect {
+		writeSOCKS5Reply(c, socks5CmdNotSupported)
+		return nil, fmt.Errorf("unsupported command %d", req[1])
+	}
+	if req[3] != socks5Domain {
+		// Services only have names.
+		writeSOCKS5Reply(c, socks5AddrTypeNotSupport)
+		return nil, fmt.Errorf("unsupported address type %d", req[3])
+	}
+
+	n, err := br.ReadByte()
+	if err != nil {
+		return nil, fmt.Errorf("could not read address: %w", err)
+	}
+	addr := make([]byte, int(n)+2) // name and port
+	if _, err := io.ReadFull(br, addr); err != nil {
+		return nil, fmt.Errorf("could not read address: %w", err)
+	}
+	host := string(addr[:n])
+
+	fconn, err := p.dial(host)
+	if err != nil {
+		writeSOCKS5Reply(c, socks5ReplyCode(err))
+		return nil, fmt.Errorf("could not dial %q: %w", host, err)
+	}
+	if err := writeSOCKS5Reply(c, socks5Succeeded); err != nil {
+		fconn.Close()
+		return nil, fmt.Errorf("could not write reply: %w", err)
+	}
+	return fconn, nil
+}
+
+// writeSOCKS5Reply writes a reply with code rep, and an empty bound address.
+func writeSOCKS5Reply(w io.Writer, rep byte) error {
+	_, err := w.Write([]byte{socks5Version, rep, 0x00, socks5IPv4, 0, 0, 0, 0, 0, 0})
+	return err
+}
+
+// socks5ReplyCode returns the reply code for an error from dial.
+func socks5ReplyCode(err error) byte {
+	switch status.Code(err) {
+	case codes.PermissionDenied, codes.Unauthenticated:
+		return socks5NotAllowed
+	case codes.NotFound:
+		return socks5HostUnreachable
+	}
+	return socks5Failure
+}
+
+func bytesContain(b []byte, x byte) bool {
+	for _, y := range b {
+		if x == y {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *SOCKSProxy) handleConnect(c net.Conn, br *bufio.Reader) (net.Conn, error) {
+	r, err := http.ReadRequest(br)
+	if err != nil {
+		return nil, fmt.Errorf("could not read HTTP request: %w", err)
+	}
+	if r.Method != http.MethodConnect {
+		writeHTTPResponse(c, http.StatusMethodNotAllowed)
+		return nil, fmt.Errorf("unsupported HTTP method %q", r.Method)
+	}
+
+	host, _, err := net.SplitHostPort(r.Host)
+	if err != nil {
+		host = r.Host
+	}
+
+	fconn, err := p.dial(host)
+	if err != nil {
+		writeHTTPResponse(c, httpStatusCode(err))
+		return nil, fmt.Errorf("could not dial %q: %w", host, err)
+	}
+	if err := writeHTTPResponse(c, http.StatusOK); err != nil {
+		fconn.Close()
+		return nil, fmt.Errorf("could not write response: %w", err)
+	}
+	return fconn, nil
+}
+
+func writeHTTPResponse(w io.Writer, code int) error {
+	_, err := fmt.Fprintf(w, "HTTP/1.1 %d %s\r\n\r\n", code, http.StatusText(code))
+	return err
+}
+
+// httpStatusCode returns the HTTP status code for a gRPC error.
+func httpStatusCode(err error) int {
+	switch status.Code(err) {
+	case codes.Unauthenticated, codes.PermissionDenied:
+		return http.StatusForbidden
+	case codes.NotFound:
+		return http.StatusNotFound
+	}
+	return http.StatusBadGateway
+}
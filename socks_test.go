@@ -0,0 +1,146 @@
+package mindmeld_test
+
+import (
+	"bufio"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"testing"
+
+	"github.com/dhowden/mindmeld"
+)
+
+// socksProxy starts a SOCKSProxy for r, returning its address.
+func socksProxy(t *testing.T, r *TestRouter) string {
+	t.Helper()
+
+	l, err := net.Listen("tcp", "localhost:0")
+	if err != nil {
+		t.Fatalf("could not listen: %v", err)
+	}
+	t.Cleanup(func() { l.Close() })
+
+	d := mindmeld.NewDialer(r.ClientConn(t))
+	t.Cleanup(func() { d.Close() })
+	go mindmeld.NewSOCKSProxy(d).Serve(l)
+	return l.Addr().String()
+}
+
+// getHTTP makes an HTTP/1.0 request on c, returning the body of the response.
+func getHTTP(t *testing.T, c net.Conn, br *bufio.Reader) string {
+	t.Helper()
+
+	io.WriteString(c, "GET /x HTTP/1.0\r\n\r\n")
+	resp, err := http.ReadResponse(br, nil)
+	if err != nil {
+		t.Fatalf("could not read response: %v", err)
+	}
+	defer resp.Body.Close()
+
+	b, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("could not read body: %v", err)
+	}
+	return string(b)
+}
+
+// socks5Connect sends a SOCKS5 CONNECT request for host, and returns the reply code.
+func socks5Connect(t *testing.T, c net.Conn, br *bufio.Reader, host string) byte {
+	t.Helper()
+
+	c.Write([]byte{0x05, 0x01, 0x00})
+	method := make([]byte, 2)
+	if _, err := io.ReadFull(br, method); err != nil {
+		t.Fatalf("could not read method: %v", err)
+	}
+	if method[1] != 0x00 {
+		t.Fatalf("method = %d, expected 0", method[1])
+	}
+
+	req := append([]byte{0x05, 0x01, 0x00, 0x03, byte(len(host))}, host...)
+	c.Write(append(req, 0, 80))
+
+	reply := make([]byte, 10)
+	if _, err := io.ReadFull(br, reply); err != nil {
+		t.Fatalf("could not read reply: %v", err)
+	}
+	return reply[1]
+}
+
+func TestSOCKSProxySOCKS5(t *testing.T) {
+	r := NewTestRouter(t)
+	serveHTTP(t, r, "app", describe("app"))
+	addr := socksProxy(t, r)
+
+	tests := []struct {
+		host string
+		rep  byte
+	}{
+		{"app.mindmeld", 0x00},
+		{"missing.mindmeld", 0x04},
+		{"example.com", 0x02},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.host, func(t *testing.T) {
+			c, err := net.Dial("tcp", addr)
+			if err != nil {
+				t.Fatalf("could not dial proxy: %v", err)
+			}
+			defer c.Close()
+			br := bufio.NewReader(c)
+
+			if got := socks5Connect(t, c, br, tt.host); got != tt.rep {
+				t.Fatalf("reply = %d, expected %d", got, tt.rep)
+			}
+			if tt.rep != 0x00 {
+				return
+			}
+			if got, want := getHTTP(t, c, br), "app /x  "; got != want {
+				t.Errorf("body = %q, expected %q", got, want)
+			}
+		})
+	}
+}
+
+func TestSOCKSProxyConnect(t *testing.T) {
+	r := NewTestRouter(t)
+	serveHTTP(t, r, "app", describe("app"))
+	addr := socksProxy(t, r)
+
+	tests := []struct {
+		host string
+		code int
+	}{
+		{"app.mindmeld:80", http.StatusOK},
+		{"missing.mindmeld:80", http.StatusNotFound},
+		{"example.com:443", http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.host, func(t *testing.T) {
+			c, err := net.Dial("tcp", addr)
+			if err != nil {
+				t.Fatalf("could not dial proxy: %v", err)
+			}
+			defer c.Close()
+			br := bufio.NewReader(c)
+
+			io.WriteString(c, "CONNECT "+tt.host+" HTTP/1.1\r\nHost: "+tt.host+"\r\n\r\n")
+			resp, err := http.ReadResponse(br, &http.Request{Method: http.MethodConnect})
+			if err != nil {
+				t.Fatalf("could not read response: %v", err)
+			}
+			if resp.StatusCode != tt.code {
+				t.Fatalf("status code = %d, expected %d", resp.StatusCode, tt.code)
+			}
+			if tt.code != http.StatusOK {
+				return
+			}
+			if got, want := getHTTP(t, c, br), "app /x  "; got != want {
+				t.Errorf("body = %q, expected %q", got, want)
+			}
+		})
+	}
+}
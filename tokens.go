@@ -0,0 +1,200 @@
+package mindmeld
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/dhowden/mindmeld/internal/metrics"
+)
+
+// DefaultTokenTTL is the default time allowed for a proxy connection to claim
+// its token.
+const DefaultTokenTTL = 30 * time.Second
+
+// NewTokenSource creates a new TokenSource, with a random key used to sign
+// tokens.
+func NewTokenSource() *TokenSource {
+	t := &TokenSource{
+		key: make([]byte, sha256.Size),
+	}
+	rand.Read(t.key)
+	return t
+}
+
+// TokenSource signs and verifies the tokens issued by a Server.
+type TokenSource struct {
+	key []byte
+
+	mu sync.Mutex
+
+	buffer [32]byte
+	n      uint64
+}
+
+// Token returns a new signed token, which is unique but carries no claims.
+//
+// Deprecated: the Server signs tokens with the service and identity they are
+// issued for, and the tokens returned by Token aren't accepted by it.
+func (t *TokenSource) Token() string {
+	return t.sign(tokenClaims{})
+}
+
+// nonce returns n random bytes followed by a counter, so that nonces are
+// unique even if the random source isn't.
+func (t *TokenSource) nonce(n int) []byte {
+	defer t.mu.Unlock()
+	t.mu.Lock()
+
+	rand.Read(t.buffer[:n])
+	binary.LittleEndian.PutUint64(t.buffer[n:], t.n)
+	t.n++
+
+	out := make([]byte, n+8)
+	copy(out, t.buffer[:n+8])
+	return out
+}
+
+// Kinds of token.
+const (
+	serviceToken byte = 's' // proxy connection from a service
+	forwardToken byte = 'f' // proxy connection from a forwarder
+)
+
+// tokenClaims are the contents of a signed token.
+type tokenClaims struct {
+	kind     byte
+	service  string
+	identity string
+	expires  time.Time
+}
+
+// Errors returned when verifying tokens.
+var (
+	errTokenInvalid = errors.New("invalid token")
+	errTokenExpired = errors.New("token expired")
+)
+
+// sign returns a token carrying c, signed (HMAC-SHA256) with the key of the
+// TokenSource.  Tokens are URL-safe base64, and don't contain ".".
+func (t *TokenSource) sign(c tokenClaims) string {
+	var b []byte
+	b = append(b, c.kind)
+	b = appendUint64(b, uint64(c.expires.UnixNano()))
+	b = append(b, t.nonce(8)...)
+	b = appendString(b, c.service)
+	b = appendString(b, c.identity)
+
+	mac := hmac.New(sha256.New, t.key)
+	mac.Write(b)
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(b))
+}
+
+// verify the signature and expiry of token, and return its claims.
+func (t *TokenSource) verify(token string) (tokenClaims, error) {
+	b, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil || len(b) < sha256.Size {
+		return tokenClaims{}, errTokenInvalid
+	}
+	b, sig := b[:len(b)-sha256.Size], b[len(b)-sha256.Size:]
+
+	mac := hmac.New(sha256.New, t.key)
+	mac.Write(b)
+	if !hmac.Equal(sig, mac.Sum(nil)) {
+		return tokenClaims{}, errTokenInvalid
+	}
+
+	// kind, expiry and nonce.
+	if len(b) < 1+8+16 {
+		return tokenClaims{}, errTokenInvalid
+	}
+	c := tokenClaims{
+		kind:    b[0],
+		expires: time.Unix(0, int64(binary.BigEndian.Uint64(b[1:9]))),
+	}
+	b = b[1+8+16:]
+
+	var ok bool
+	if c.service, b, ok = readString(b); !ok {
+		return tokenClaims{}, errTokenInvalid
+	}
+	if c.identity, _, ok = readString(b); !ok {
+		return tokenClaims{}, errTokenInvalid
+	}
+
+	if time.Now().After(c.expires) {
+		return c, errTokenExpired
+	}
+	return c, nil
+}
+
+func appendUint64(b []byte, x uint64) []byte {
+	var buf [8]byte
+	binary.BigEndian.PutUint64(buf[:], x)
+	return append(b, buf[:]...)
+}
+
+func appendString(b []byte, s string) []byte {
+	var buf [binary.MaxVarintLen64]byte
+	n := binary.PutUvarint(buf[:], uint64(len(s)))
+	b = append(b, buf[:n]...)
+	return append(b, s...)
+}
+
+func readString(b []byte) (string, []byte, bool) {
+	n, m := binary.Uvarint(b)
+	if m <= 0 || uint64(len(b)-m) < n {
+		return "", nil, false
+	}
+	b = b[m:]
+	return string(b[:n]), b[n:], true
+}
+
+// TokenStats are counts of the tokens issued by a Server.
+type TokenStats struct {
+	// Issued is the number of tokens issued.
+	Issued uint64
+
+	// Claimed is the number of tokens claimed by proxy connections.
+	Claimed uint64
+
+	// Expired is the number of tokens which expired before being claimed.
+	Expired uint64
+
+	// Invalid is the number of proxy connections which presented a token
+	// that wasn't issued by the Server, or had already been claimed.
+	Invalid uint64
+}
+
+// tokenStats are the counters behind TokenStats.
+type tokenStats struct {
+	issued, claimed, expired, invalid uint64
+
+	counter *metrics.Vec // by event
+}
+
+func (t *tokenStats) issue()       { t.add(&t.issued, "issued", 1) }
+func (t *tokenStats) claim()       { t.add(&t.claimed, "claimed", 1) }
+func (t *tokenStats) expire(n int) { t.add(&t.expired, "expired", uint64(n)) }
+func (t *tokenStats) invalidate()  { t.add(&t.invalid, "invalid", 1) }
+
+// add n to the counter p (and its metric).
+func (t *tokenStats) add(p *uint64, event string, n uint64) {
+	atomic.AddUint64(p, n)
+	t.counter.With(event).Add(float64(n))
+}
+
+func (t *tokenStats) stats() TokenStats {
+	return TokenStats{
+		Issued:  atomic.LoadUint64(&t.issued),
+		Claimed: atomic.LoadUint64(&t.claimed),
+		Expired: atomic.LoadUint64(&t.expired),
+		Invalid: atomic.LoadUint64(&t.invalid),
+	}
+}
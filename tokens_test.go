@@ -0,0 +1,163 @@
+package mindmeld_test
+
+import (
+	"context"
+	"io/ioutil"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+
+	"github.com/dhowden/mindmeld"
+	"github.com/dhowden/mindmeld/internal"
+	"github.com/dhowden/mindmeld/internal/protoproxy"
+	"github.com/dhowden/mindmeld/pb"
+)
+
+// proxyRead creates a proxy connection using token, and returns everything
+// read from it.
+func proxyRead(t *testing.T, cc *grpc.ClientConn, token string) string {
+	t.Helper()
+
+	c, err := protoproxy.Dial(cc)
+	if err != nil {
+		t.Fatalf("Dial() = %v", err)
+	}
+	defer c.Close()
+
+	if err := internal.WriteHeader(c, &pb.Header{Token: token}); err != nil {
+		t.Fatalf("WriteHeader() = %v", err)
+	}
+	b, _ := ioutil.ReadAll(c)
+	return string(b)
+}
+
+func TestTokens(t *testing.T) {
+	const ttl = 100 * time.Millisecond
+
+	r := NewTestRouter(t, mindmeld.WithTokenTTL(ttl))
+	cc := r.ClientConn(t)
+
+	l, err := mindmeld.Listen(context.Background(), cc, "svc")
+	if err != nil {
+		t.Fatalf("Listen() = %v", err)
+	}
+	defer l.Close()
+	go serveName(l, "svc")
+	waitForService(t, cc, "svc")
+
+	forward := func() string {
+		resp, err := pb.NewControlServiceClient(cc).ForwardToService(context.Background(), &pb.ForwardToServiceRequest{
+			Name: "svc",
+		})
+		if err != nil {
+			t.Fatalf("ForwardToService() = %v", err)
+		}
+		return resp.GetToken()
+	}
+
+	token := forward()
+	if got := proxyRead(t, cc, token); got != "svc" {
+		t.Errorf("proxyRead() = %q, want %q", got, "svc")
+	}
+	if got := proxyRead(t, cc, token); got != "" {
+		t.Errorf("proxyRead() = %q for claimed token, want %q", got, "")
+	}
+
+	tampered := []byte(forward())
+	tampered[len(tampered)/2] ^= 1
+	if got := proxyRead(t, cc, string(tampered)); got != "" {
+		t.Errorf("proxyRead() = %q for tampered token, want %q", got, "")
+	}
+
+	expired := forward()
+	time.Sleep(2 * ttl)
+	if got := proxyRead(t, cc, expired); got != "" {
+		t.Errorf("proxyRead() = %q for expired token, want %q", got, "")
+	}
+
+	// Expired tokens are swept (the tampered and expired tokens).
+	want := mindmeld.TokenStats{
+		Issued:  4, // 3 forwards, 1 service
+		Claimed: 2, // forward and service
+		Expired: 2,
+		Invalid: 2, // claimed and tampered
+	}
+	deadline := time.Now().Add(time.Second)
+	for r.TokenStats() != want && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if got := r.TokenStats(); got != want {
+		t.Errorf("TokenStats() = %+v, want %+v", got, want)
+	}
+}
+
+func TestServiceTokenExpiry(t *testing.T) {
+	const ttl = 100 * time.Millisecond
+
+	r := NewTestRouter(t, mindmeld.WithTokenTTL(ttl))
+	cc := r.ClientConn(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	csc, err := pb.NewControlServiceClient(cc).CreateService(ctx, &pb.CreateServiceRequest{Name: "svc"})
+	if err != nil {
+		t.Fatalf("CreateService() = %v", err)
+	}
+	if _, err := csc.Header(); err != nil {
+		t.Fatalf("Header() = %v", err)
+	}
+
+	resp, err := pb.NewControlServiceClient(cc).ForwardToService(context.Background(), &pb.ForwardToServiceRequest{
+		Name: "svc",
+	})
+	if err != nil {
+		t.Fatalf("ForwardToService() = %v", err)
+	}
+	c, err := protoproxy.Dial(cc)
+	if err != nil {
+		t.Fatalf("Dial() = %v", err)
+	}
+	defer c.Close()
+	if err := internal.WriteHeader(c, &pb.Header{Token: resp.GetToken()}); err != nil {
+		t.Fatalf("WriteHeader() = %v", err)
+	}
+
+	// The service never claims its token, so the forward is abandoned.
+	if _, err := csc.Recv(); err != nil {
+		t.Fatalf("Recv() = %v", err)
+	}
+	if b, _ := ioutil.ReadAll(c); len(b) != 0 {
+		t.Errorf("read %q from abandoned forward, expected nothing", b)
+	}
+
+	// The expired service token is counted once (whether it is swept or
+	// removed by the forward).
+	time.Sleep(2 * ttl)
+	want := mindmeld.TokenStats{
+		Issued:  2,
+		Claimed: 1,
+		Expired: 1,
+	}
+	if got := r.TokenStats(); got != want {
+		t.Errorf("TokenStats() = %+v, want %+v", got, want)
+	}
+}
+
+func TestTokenTTLNotPositive(t *testing.T) {
+	// Invalid TTLs are ignored (rather than stopping the sweeper).
+	r := NewTestRouter(t, mindmeld.WithTokenTTL(0))
+	cc := r.ClientConn(t)
+
+	l, err := mindmeld.Listen(context.Background(), cc, "svc")
+	if err != nil {
+		t.Fatalf("Listen() = %v", err)
+	}
+	defer l.Close()
+	go serveName(l, "svc")
+	waitForService(t, cc, "svc")
+
+	if got := dialRead(t, cc, "svc"); got != "svc" {
+		t.Errorf("dialRead() = %q, want %q", got, "svc")
+	}
+}
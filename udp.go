@@ -0,0 +1,202 @@
+package mindmeld
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/dhowden/mindmeld/internal"
+)
+
+// DefaultIdleTimeout is the default time after which UDP sessions with no
+// traffic are closed.
+const DefaultIdleTimeout = 2 * time.Minute
+
+// datagramConn carries datagrams over a stream connection, so that each Read
+// and Write is a single datagram.  Datagrams are framed in the byte stream
+// (see internal.WriteDatagram) rather than as individual pb.Payload messages,
+// so they work unchanged over both single and multiplexed proxying
+// connections.  Reads must not be called concurrently.
+type datagramConn struct {
+	net.Conn
+}
+
+// Read reads a single datagram into b.  If b is too small then the rest of the
+// datagram is discarded.
+func (c *datagramConn) Read(b []byte) (int, error) {
+	return internal.ReadDatagram(c.Conn, b)
+}
+
+// Write writes b as a single datagram.
+func (c *datagramConn) Write(b []byte) (int, error) {
+	if err := internal.WriteDatagram(c.Conn, b); err != nil {
+		return 0, err
+	}
+	return len(b), nil
+}
+
+// relayDatagrams copies datagrams between a and b until either direction
+// fails, done is closed, or there has been no traffic for the idle timeout.
+// Callers should close a and b afterwards.
+func relayDatagrams(a, b io.ReadWriter, idle time.Duration, done <-chan bool) error {
+	idleCh := make(chan struct{})
+	var idleOnce sync.Once
+	t := time.AfterFunc(idle, func() {
+		idleOnce.Do(func() { close(idleCh) })
+	})
+	defer t.Stop()
+
+	errc := make(chan error, 2)
+	go cpDatagrams(a, b, t, idle, errc)
+	go cpDatagrams(b, a, t, idle, errc)
+
+	select {
+	case err := <-errc:
+		if err == io.EOF {
+			return nil
+		}
+		return err
+	case <-idleCh:
+		return nil
+	case <-done:
+		return nil
+	}
+}
+
+// cpDatagrams copies datagrams from r to w, resetting t to idle after each
+// one.
+func cpDatagrams(w io.Writer, r io.Reader, t *time.Timer, idle time.Duration, errc chan error) {
+	buf := make([]byte, internal.MaxDatagramSize)
+	for {
+		n, err := r.Read(buf)
+		if err != nil {
+			errc <- err
+			return
+		}
+		t.Reset(idle)
+
+		if _, err := w.Write(buf[:n]); err != nil {
+			errc <- err
+			return
+		}
+	}
+}
+
+// forwardDatagrams receives datagrams on a local UDP socket and forwards
+// them to the service.  Each peer of the socket gets its own session (and
+// proxy connection), which is closed after the idle timeout.
+func (fc *ForwardClient) forwardDatagrams(addr string) error {
+	pc, err := net.ListenPacket("udp", addr)
+	if err != nil {
+		return fmt.Errorf("could not listen for incoming datagrams: %v", err)
+	}
+	defer pc.Close()
+	go fc.closeOnDone(pc)
+
+	var mu sync.Mutex // protects peers
+	peers := make(map[string]*peerConn)
+
+	buf := make([]byte, internal.MaxDatagramSize)
+	for {
+		n, from, err := pc.ReadFrom(buf)
+		if err != nil {
+			if fc.isClosed() {
+				return nil
+			}
+			return fmt.Errorf("could not read incoming datagram: %v", err)
+		}
+
+		key := from.String()
+		mu.Lock()
+		p, ok := peers[key]
+		if !ok {
+			p = newPeerConn(pc, from)
+			peers[key] = p
+		}
+		mu.Unlock()
+
+		if !ok {
+			go func() {
+				fc.handleSession(p)
+
+				mu.Lock()
+				delete(peers, key)
+				mu.Unlock()
+				p.Close()
+			}()
+		}
+		p.deliver(append([]byte(nil), buf[:n]...))
+	}
+}
+
+func (fc *ForwardClient) handleSession(p *peerConn) {
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+
+	fconn, err := fc.d.DialContext(ctx, "udp", fc.service)
+	if err != nil {
+		log.Printf("Could not dial service %q: %v", fc.service, err)
+		return
+	}
+	defer fconn.Close()
+
+	log.Printf("Created session from %v for forward to %q", p.addr, fc.service)
+	defer log.Printf("Closing session from %v for forward to %q", p.addr, fc.service)
+
+	if err := relayDatagrams(fconn, p, fc.opts.idleTimeout, fc.done); err != nil {
+		log.Printf("Forwarding ended: %v", err)
+	}
+}
+
+func newPeerConn(pc net.PacketConn, addr net.Addr) *peerConn {
+	return &peerConn{
+		pc:     pc,
+		addr:   addr,
+		in:     make(chan []byte, 64),
+		closed: make(chan struct{}),
+	}
+}
+
+// peerConn exchanges datagrams with a single peer of a shared
+// net.PacketConn.  Incoming datagrams are passed in using deliver.
+type peerConn struct {
+	pc   net.PacketConn
+	addr net.Addr
+	in   chan []byte
+
+	closeOnce sync.Once
+	closed    chan struct{}
+}
+
+// deliver a datagram received from the peer.  As with UDP, datagrams are
+// dropped if they can't be handled quickly enough.
+func (p *peerConn) deliver(b []byte) {
+	select {
+	case p.in <- b:
+	default:
+	}
+}
+
+func (p *peerConn) Read(b []byte) (int, error) {
+	select {
+	case d := <-p.in:
+		return copy(b, d), nil
+	case <-p.closed:
+		return 0, net.ErrClosed
+	}
+}
+
+func (p *peerConn) Write(b []byte) (int, error) {
+	return p.pc.WriteTo(b, p.addr)
+}
+
+func (p *peerConn) Close() error {
+	p.closeOnce.Do(func() {
+		close(p.closed)
+	})
+	return nil
+}
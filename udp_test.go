@@ -0,0 +1,137 @@
+package mindmeld_test
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/dhowden/mindmeld"
+)
+
+// udpEcho starts a UDP server which echoes datagrams back to their sender.
+func udpEcho(t *testing.T) net.PacketConn {
+	t.Helper()
+
+	pc, err := net.ListenPacket("udp", "localhost:0")
+	if err != nil {
+		t.Fatalf("could not listen: %v", err)
+	}
+	t.Cleanup(func() { pc.Close() })
+
+	go func() {
+		buf := make([]byte, 65535)
+		for {
+			n, addr, err := pc.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+			pc.WriteTo(buf[:n], addr)
+		}
+	}()
+	return pc
+}
+
+// registerUDPEcho registers a UDP echo service with the router.
+func registerUDPEcho(t *testing.T, r *TestRouter, name string) {
+	t.Helper()
+
+	cc := r.ClientConn(t)
+	pc := udpEcho(t)
+
+	sc := mindmeld.NewServiceClient(cc, name, "udp:"+pc.LocalAddr().String())
+	t.Cleanup(func() { sc.Close() })
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	go sc.Register(ctx)
+
+	waitForService(t, cc, name)
+}
+
+// exchange writes each message to c and checks that it is echoed back as a
+// single datagram.
+func exchange(t *testing.T, c net.Conn, msgs ...string) {
+	t.Helper()
+
+	buf := make([]byte, 1024)
+	for _, msg := range msgs {
+		if _, err := c.Write([]byte(msg)); err != nil {
+			t.Fatalf("write failed: %v", err)
+		}
+		c.SetReadDeadline(time.Now().Add(time.Second))
+		n, err := c.Read(buf)
+		if err != nil {
+			t.Fatalf("read failed: %v", err)
+		}
+		if got := string(buf[:n]); got != msg {
+			t.Errorf("read %q, expected %q", got, msg)
+		}
+	}
+}
+
+func TestDialUDP(t *testing.T) {
+	r := NewTestRouter(t)
+	registerUDPEcho(t, r, "echo")
+
+	d := mindmeld.NewDialer(r.ClientConn(t))
+	c, err := d.DialContext(context.Background(), "udp", "echo")
+	if err != nil {
+		t.Fatalf("DialContext() = %v", err)
+	}
+	defer c.Close()
+
+	exchange(t, c, "one", "two", "three")
+}
+
+func TestDialUDPProtocolMismatch(t *testing.T) {
+	r := NewTestRouter(t)
+	registerUDPEcho(t, r, "echo")
+
+	d := mindmeld.NewDialer(r.ClientConn(t))
+	_, err := d.DialContext(context.Background(), "tcp", "echo")
+	if got := status.Code(err); got != codes.FailedPrecondition {
+		t.Errorf("DialContext() = %v, expected code %v", err, codes.FailedPrecondition)
+	}
+}
+
+func TestForwardUDP(t *testing.T) {
+	r := NewTestRouter(t)
+	registerUDPEcho(t, r, "echo")
+
+	// Find a free port for the forward.
+	pc, err := net.ListenPacket("udp", "localhost:0")
+	if err != nil {
+		t.Fatalf("could not listen: %v", err)
+	}
+	addr := pc.LocalAddr().String()
+	pc.Close()
+
+	fc := mindmeld.NewForwardClient(r.ClientConn(t), "echo", "udp:"+addr)
+	defer fc.Close()
+	go fc.Forward()
+
+	// Wait for the forward to bind the address.
+	for {
+		pc, err := net.ListenPacket("udp", addr)
+		if err != nil {
+			break
+		}
+		pc.Close()
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	// Each peer gets its own session.
+	for i := 0; i < 2; i++ {
+		c, err := net.Dial("udp", addr)
+		if err != nil {
+			t.Fatalf("could not dial forward: %v", err)
+		}
+		defer c.Close()
+
+		exchange(t, c, "hello", "world")
+	}
+}
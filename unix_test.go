@@ -0,0 +1,152 @@
+package mindmeld_test
+
+import (
+	"context"
+	"io"
+	"io/ioutil"
+	"net"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/dhowden/mindmeld"
+)
+
+func TestServiceClientUnix(t *testing.T) {
+	r := NewTestRouter(t)
+	cc := r.ClientConn(t)
+
+	path := filepath.Join(t.TempDir(), "target.sock")
+	l, err := net.Listen("unix", path)
+	if err != nil {
+		t.Fatalf("could not listen: %v", err)
+	}
+	defer l.Close()
+	go countServer(l)
+
+	sc := mindmeld.NewServiceClient(cc, "count", "unix:"+path)
+	defer sc.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go sc.Register(ctx)
+
+	waitForService(t, cc, "count")
+
+	c, err := mindmeld.NewDialer(cc).DialContext(context.Background(), "tcp", "count")
+	if err != nil {
+		t.Fatalf("DialContext() = %v", err)
+	}
+	defer c.Close()
+
+	io.WriteString(c, "hello")
+	c.(interface{ CloseWrite() error }).CloseWrite()
+
+	b, err := ioutil.ReadAll(c)
+	if err != nil {
+		t.Fatalf("could not read: %v", err)
+	}
+	if got, want := string(b), "5"; got != want {
+		t.Errorf("read %q, expected %q", got, want)
+	}
+}
+
+// dialUnix dials the unix socket at path, retrying until it is listening.
+func dialUnix(t *testing.T, path string) net.Conn {
+	t.Helper()
+
+	deadline := time.Now().Add(time.Second)
+	for {
+		c, err := net.Dial("unix", path)
+		if err == nil {
+			return c
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("could not dial %v: %v", path, err)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestForwardClientUnix(t *testing.T) {
+	r := NewTestRouter(t)
+	cc := r.ClientConn(t)
+
+	l, err := mindmeld.Listen(context.Background(), cc, "svc")
+	if err != nil {
+		t.Fatalf("Listen() = %v", err)
+	}
+	defer l.Close()
+	go serveName(l, "svc")
+
+	path := filepath.Join(t.TempDir(), "forward.sock")
+
+	// A socket left behind by a previous process is replaced.
+	stale, err := net.Listen("unix", path)
+	if err != nil {
+		t.Fatalf("could not listen: %v", err)
+	}
+	stale.(*net.UnixListener).SetUnlinkOnClose(false)
+	stale.Close()
+
+	fc := mindmeld.NewForwardClient(cc, "svc", "unix:"+path)
+	defer fc.Close()
+	go fc.Forward()
+
+	c := dialUnix(t, path)
+	defer c.Close()
+
+	b, err := ioutil.ReadAll(c)
+	if err != nil {
+		t.Fatalf("could not read: %v", err)
+	}
+	if got, want := string(b), "svc"; got != want {
+		t.Errorf("read %q, expected %q", got, want)
+	}
+}
+
+func TestForwardClientClose(t *testing.T) {
+	r := NewTestRouter(t)
+
+	path := filepath.Join(t.TempDir(), "forward.sock")
+	fc := mindmeld.NewForwardClient(r.ClientConn(t), "svc", "unix:"+path)
+
+	errc := make(chan error, 1)
+	go func() { errc <- fc.Forward() }()
+
+	dialUnix(t, path).Close()
+	fc.Close()
+
+	select {
+	case err := <-errc:
+		if err != nil {
+			t.Errorf("Forward() = %v, expected nil", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("Forward() did not return after Close")
+	}
+}
+
+func TestForwardClientUnixNotSocket(t *testing.T) {
+	r := NewTestRouter(t)
+
+	// Only stale sockets are replaced: other files are left alone.
+	path := filepath.Join(t.TempDir(), "forward.sock")
+	if err := ioutil.WriteFile(path, []byte("data"), 0600); err != nil {
+		t.Fatalf("could not write file: %v", err)
+	}
+
+	fc := mindmeld.NewForwardClient(r.ClientConn(t), "svc", "unix:"+path)
+	defer fc.Close()
+	if err := fc.Forward(); err == nil {
+		t.Errorf("Forward() = nil, expected error")
+	}
+
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatalf("could not read file: %v", err)
+	}
+	if got, want := string(b), "data"; got != want {
+		t.Errorf("file contains %q, expected %q", got, want)
+	}
+}